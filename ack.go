@@ -0,0 +1,94 @@
+package glow
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// packet carries data over a Link.
+type packet struct {
+	data    any
+	ack     *ack          // nil unless seed-node has AckFunc set
+	barrier int64         // id of the checkpoint the packet is a barrier for, zero for data
+	offset  int64         // offset of the data in the log of durable Link, zero if not logged
+	logging *pendingLog   // set while data handed over durable Link is being logged
+	flushed chan struct{} // closed once data ahead of the barrier is forwarded, set within a Node
+}
+
+// ack tracks a data point emitted by a seed-node, and everything derived from it, through the Network.
+// Every pending delivery and every pending forwarding holds a count on the ack.
+// Once all counts are released, AckFunc of the seed-node is called.
+type ack struct {
+	data    any
+	f       func(any, error)
+	book    *sync.Map // outstanding acks of the session
+	pending atomic.Int64
+	err     atomic.Pointer[error]
+	once    sync.Once
+}
+
+// AckFunc enables acknowledgements for data emitted by the seed-node.
+// The function is called once for every emitted data point when the data point and
+// all the data derived from it are consumed by the Network.
+// Error is nil if all the Node functions succeeded, otherwise it is the first error
+// returned by any Node function for the data point or ErrDataDropped if
+// the data point was dropped, e.g. when the Network stopped.
+// AckFunc has no effect on transit and terminal nodes.
+func AckFunc(f func(data any, err error)) NodeOpt {
+	return func(n *Node) {
+		n.ack = f
+	}
+}
+
+// newAck creates an ack holding a count for the forwarding of the data.
+func newAck(data any, f func(any, error), book *sync.Map) *ack {
+	a := &ack{
+		data: data,
+		f:    f,
+		book: book,
+	}
+	a.pending.Store(1)
+	book.Store(a, struct{}{})
+	return a
+}
+
+// add holds a count on the ack.
+func (a *ack) add() {
+	if a == nil {
+		return
+	}
+	a.pending.Add(1)
+}
+
+// done releases a count on the ack, err is recorded if it is the first error.
+func (a *ack) done(err error) {
+	if a == nil {
+		return
+	}
+	if err != nil {
+		a.err.CompareAndSwap(nil, &err)
+	}
+	if a.pending.Add(-1) == 0 {
+		var err error
+		if p := a.err.Load(); p != nil {
+			err = *p
+		}
+		a.fire(err)
+	}
+}
+
+// fire calls the AckFunc once, regardless of pending counts.
+func (a *ack) fire(err error) {
+	a.once.Do(func() {
+		a.book.Delete(a)
+		a.f(a.data, err)
+	})
+}
+
+// dropAcks fails all outstanding acks of the session.
+func dropAcks(book *sync.Map) {
+	book.Range(func(k, _ any) bool {
+		k.(*ack).fire(ErrDataDropped)
+		return true
+	})
+}
@@ -0,0 +1,124 @@
+package glow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backpressure captures what the from-node does when the Link is full.
+type backpressure struct {
+	mode    backpressureMode
+	timeout time.Duration // in block mode, zero waits indefinitely
+	every   int           // in sample mode, one in every data points waits for room
+}
+
+type backpressureMode int
+
+const (
+	blockMode backpressureMode = iota
+	dropNewestMode
+	dropOldestMode
+	sampleMode
+)
+
+// Block makes the from-node wait for room in the Link when it is full, which is the default.
+func Block() LinkOpt {
+	return func(l *Link) {
+		l.backpressure = backpressure{mode: blockMode}
+	}
+}
+
+// BlockFor makes the from-node wait for room in the Link up to the timeout when it is full.
+// Data is dropped once the timeout runs out.
+func BlockFor(timeout time.Duration) LinkOpt {
+	return func(l *Link) {
+		l.backpressure = backpressure{mode: blockMode, timeout: max(timeout, 0)}
+	}
+}
+
+// DropNewest makes the from-node drop the data it is sending when the Link is full.
+func DropNewest() LinkOpt {
+	return func(l *Link) {
+		l.backpressure = backpressure{mode: dropNewestMode}
+	}
+}
+
+// DropOldest makes the from-node drop the data waiting the longest in the Link to make room when the Link is full.
+// Link without bandwidth (see Size) holds no data, so the data being sent is dropped instead.
+func DropOldest() LinkOpt {
+	return func(l *Link) {
+		l.backpressure = backpressure{mode: dropOldestMode}
+	}
+}
+
+// Sample makes the from-node wait for room in the Link for one in every k data points when the Link is full,
+// and drop the rest.
+func Sample(k int) LinkOpt {
+	return func(l *Link) {
+		l.backpressure = backpressure{mode: sampleMode, every: max(k, 1)}
+	}
+}
+
+// String describes the backpressure as it appears in the DOT description of the Network.
+func (b backpressure) String() string {
+	switch b.mode {
+	case dropNewestMode:
+		return "drop-newest"
+	case dropOldestMode:
+		return "drop-oldest"
+	case sampleMode:
+		return fmt.Sprintf("sample:%d", b.every)
+	default:
+		if b.timeout > 0 {
+			return fmt.Sprintf("block:%s", b.timeout)
+		}
+		return "block"
+	}
+}
+
+// parseBackpressure returns LinkOpt for the backpressure described by String.
+func parseBackpressure(s string) (LinkOpt, bool) {
+	mode, arg, _ := strings.Cut(s, ":")
+	switch mode {
+	case "block":
+		if arg == "" {
+			return Block(), true
+		}
+		timeout, err := time.ParseDuration(arg)
+		if err != nil || timeout < 0 {
+			return nil, false
+		}
+		return BlockFor(timeout), true
+	case "drop-newest":
+		return DropNewest(), arg == ""
+	case "drop-oldest":
+		return DropOldest(), arg == ""
+	case "sample":
+		k, err := strconv.Atoi(arg)
+		if err != nil || k < 1 {
+			return nil, false
+		}
+		return Sample(k), true
+	default:
+		return nil, false
+	}
+}
+
+// Dropped returns the total count of data dropped by the Link thus far as it was full.
+// See:
+//   - BlockFor
+//   - DropNewest
+//   - DropOldest
+//   - Sample
+func (l *Link) Dropped() int {
+	return int(l.dropped.Load())
+}
+
+// drop discards data that didn't make it into the Link.
+func (n *Network) drop(link *Link, p packet) {
+	link.dropped.Add(1)
+	p.ack.done(ErrDataDropped)
+	n.log().Debug("Dropped data", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+}
@@ -0,0 +1,400 @@
+package glow
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Checkpoint captures the state of the stateful Node(s) at a point consistent across the Network.
+type Checkpoint struct {
+	ID     int64
+	States map[string][]byte // state by Node key
+}
+
+// CheckpointStore keeps checkpoints of the Network.
+type CheckpointStore interface {
+	// Save stores the complete checkpoint.
+	Save(cp Checkpoint) error
+	// Load returns the last saved checkpoint, it reports false if there is none.
+	Load() (Checkpoint, bool, error)
+}
+
+// Checkpoints enables coordinated checkpoints of the Network, taken every interval while the Network is running
+// and saved to the store. Nil store keeps checkpoints in the "checkpoints" directory, see DirStore.
+//
+// Seed-nodes take a checkpoint as they emit data, and send a barrier over their egress Link(s) behind the data
+// emitted so far. Other nodes take the checkpoint once the barrier arrived over all their ingress Link(s),
+// holding data coming in after the barrier until then. The checkpoint is complete once all running nodes took it.
+// Stateful nodes (see State) save their state with the checkpoint.
+//
+// A checkpoint not complete by the next interval is abandoned in favor of the next one. A seed-node that isn't
+// emitting data holds the checkpoint up, and barriers evicted from full Link(s) with DropOldest are lost.
+//
+// Network.Start resumes from the last complete checkpoint by restoring the state of stateful nodes, and it saves
+// a final checkpoint when the session ends without error or being stopped.
+// Checkpoints require the Network to be free of cycles.
+func Checkpoints(store CheckpointStore, every time.Duration) NetworkOpt {
+	return func(n *Network) {
+		if store == nil {
+			store = DirStore("checkpoints")
+		}
+		n.checkpoints = &checkpointer{
+			store:    store,
+			every:    every,
+			complete: make(chan Checkpoint, 1),
+		}
+	}
+}
+
+// State makes the Node stateful, its state is saved with checkpoints and restored from them.
+// Snapshot is never called while the Node function is processing incoming data.
+// A seed-node takes a checkpoint from within emit, right after emitted data is handed over,
+// so its state must account for that data.
+// See:
+//   - Checkpoints
+func State(snapshot func() ([]byte, error), restore func([]byte) error) NodeOpt {
+	return func(n *Node) {
+		n.snapshot = snapshot
+		n.restore = restore
+	}
+}
+
+// DirStore returns CheckpointStore keeping checkpoints as files in the directory.
+// Only the last saved checkpoint is kept.
+func DirStore(dir string) CheckpointStore {
+	return dirStore{dir: dir}
+}
+
+type dirStore struct {
+	dir string
+}
+
+const checkpointExt = ".checkpoint"
+
+func (s dirStore) Save(cp Checkpoint) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err = gob.NewEncoder(f).Encode(cp); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", cp.ID, checkpointExt))
+	if err = os.Rename(f.Name(), name); err != nil {
+		return err
+	}
+
+	names, err := s.names()
+	if err != nil {
+		return err
+	}
+	for _, old := range names[:len(names)-1] {
+		_ = os.Remove(filepath.Join(s.dir, old))
+	}
+	return nil
+}
+
+func (s dirStore) Load() (Checkpoint, bool, error) {
+	names, err := s.names()
+	if err != nil || len(names) == 0 {
+		return Checkpoint{}, false, err
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, names[len(names)-1]))
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	defer f.Close()
+
+	var cp Checkpoint
+	if err = gob.NewDecoder(f).Decode(&cp); err != nil {
+		return Checkpoint{}, false, err
+	}
+	return cp, true, nil
+}
+
+// names returns names of the checkpoint files in the order they were saved.
+func (s dirStore) names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), checkpointExt) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// checkpointer coordinates checkpoints of the Network, it is safe for concurrent use.
+type checkpointer struct {
+	store    CheckpointStore
+	every    time.Duration
+	complete chan Checkpoint // complete checkpoints to be saved
+	saved    atomic.Int64    // id of the last saved checkpoint
+
+	mu      sync.Mutex
+	last    int64           // id of the last checkpoint begun or restored
+	pending *Checkpoint     // checkpoint in progress
+	waiting map[string]bool // nodes yet to take the pending checkpoint
+}
+
+// begin starts a checkpoint to be taken by the nodes identified by the keys.
+// The checkpoint in progress, if any, is abandoned.
+func (c *checkpointer) begin(keys []string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last++
+	c.pending = &Checkpoint{ID: c.last, States: make(map[string][]byte)}
+	c.waiting = make(map[string]bool)
+	for _, key := range keys {
+		c.waiting[key] = true
+	}
+	return c.last
+}
+
+// take records the state of the Node for the checkpoint, nil state is not recorded.
+// The checkpoint is abandoned if taking the state failed.
+func (c *checkpointer) take(id int64, key string, state []byte, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil || c.pending.ID != id || !c.waiting[key] {
+		return
+	}
+	if err != nil {
+		c.abandon()
+		return
+	}
+	if state != nil {
+		c.pending.States[key] = state
+	}
+	delete(c.waiting, key)
+	if len(c.waiting) > 0 {
+		return
+	}
+	cp := *c.pending
+	c.abandon()
+
+	// a complete checkpoint not saved yet is superseded
+	select {
+	case <-c.complete:
+	default:
+	}
+	c.complete <- cp
+}
+
+// gone abandons the checkpoint in progress if the Node went away before taking it.
+func (c *checkpointer) gone(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending != nil && c.waiting[key] {
+		c.abandon()
+	}
+}
+
+// abandon drops the checkpoint in progress. Must be called with checkpointer.mu held.
+func (c *checkpointer) abandon() {
+	c.pending = nil
+	c.waiting = nil
+}
+
+// next returns the id for a checkpoint taken outside the session.
+func (c *checkpointer) next() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.abandon()
+	c.last++
+	return c.last
+}
+
+// resume restores the state of stateful Node(s) from the last complete checkpoint, if any.
+func (n *Network) resume() error {
+	cp, ok, err := n.checkpoints.store.Load()
+	if err != nil || !ok {
+		return err
+	}
+
+	n.checkpoints.mu.Lock()
+	n.checkpoints.abandon()
+	n.checkpoints.last = cp.ID
+	n.checkpoints.mu.Unlock()
+	n.checkpoints.saved.Store(cp.ID)
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for key, state := range cp.States {
+		node, ok := n.nodes[key]
+		if !ok || node.restore == nil {
+			continue
+		}
+		if err = node.restore(state); err != nil {
+			return fmt.Errorf("restoring %s from checkpoint %d: %w", key, cp.ID, err)
+		}
+	}
+	n.log().Info("Network resumed", "checkpoint", cp.ID)
+	return nil
+}
+
+// runCheckpoints triggers checkpoints periodically and saves complete ones until ctx is done.
+func (n *Network) runCheckpoints(ctx context.Context) {
+	var tick <-chan time.Time
+	if n.checkpoints.every > 0 {
+		ticker := time.NewTicker(n.checkpoints.every)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			select {
+			case cp := <-n.checkpoints.complete:
+				n.saveCheckpoint(cp)
+			default:
+			}
+			return
+		case <-tick:
+			n.triggerCheckpoint()
+		case cp := <-n.checkpoints.complete:
+			n.saveCheckpoint(cp)
+		}
+	}
+}
+
+// triggerCheckpoint begins a checkpoint with all running nodes, and signals seed-nodes to take it.
+// The checkpoint in progress, if any, is abandoned as it didn't complete within the interval.
+func (n *Network) triggerCheckpoint() {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if !n.running() {
+		return
+	}
+
+	var keys []string
+	var seeds []*nodeRun
+	for key, run := range n.session.runs {
+		if run.isDone() {
+			continue
+		}
+		keys = append(keys, key)
+		if run.seed {
+			seeds = append(seeds, run)
+		}
+	}
+	if len(seeds) == 0 {
+		return
+	}
+
+	id := n.checkpoints.begin(keys)
+	n.log().Debug("Checkpoint begun", "checkpoint", id)
+
+	for _, run := range seeds {
+		// replace the signal for an abandoned checkpoint, if any
+		select {
+		case <-run.trigger:
+		default:
+		}
+		select {
+		case run.trigger <- id:
+		default:
+		}
+	}
+}
+
+// takeCheckpoint records the state of the Node for the checkpoint.
+func (n *Network) takeCheckpoint(node *Node, id int64) {
+	var state []byte
+	var err error
+	if node.snapshot != nil {
+		state, err = node.snapshot()
+		if err != nil {
+			n.log().Error("Checkpoint abandoned", "checkpoint", id, "node", node.Key(), "error", err)
+		}
+	}
+	n.checkpoints.take(id, node.Key(), state, err)
+}
+
+func (n *Network) saveCheckpoint(cp Checkpoint) {
+	if err := n.checkpoints.store.Save(cp); err != nil {
+		n.log().Error("Checkpoint not saved", "checkpoint", cp.ID, "error", err)
+		return
+	}
+	n.checkpoints.saved.Store(cp.ID)
+	n.log().Info("Checkpoint saved", "checkpoint", cp.ID)
+}
+
+// finalCheckpoint saves the state of all stateful nodes once the session ended without error or being stopped.
+func (n *Network) finalCheckpoint() error {
+	n.mu.RLock()
+	cp := Checkpoint{ID: n.checkpoints.next(), States: make(map[string][]byte)}
+	for key, node := range n.nodes {
+		if node.snapshot == nil {
+			continue
+		}
+		state, err := node.snapshot()
+		if err != nil {
+			n.mu.RUnlock()
+			return fmt.Errorf("final checkpoint of %s: %w", key, err)
+		}
+		cp.States[key] = state
+	}
+	n.mu.RUnlock()
+
+	if err := n.checkpoints.store.Save(cp); err != nil {
+		return err
+	}
+	n.checkpoints.saved.Store(cp.ID)
+	n.log().Info("Checkpoint saved", "checkpoint", cp.ID)
+	return nil
+}
+
+// broadcastBarrier sends the checkpoint barrier over all the egress Link(s) of the Node,
+// whatever the operating mode of the Node is.
+// It returns false if ctx is done before the barrier is sent.
+func (n *Network) broadcastBarrier(ctx context.Context, node *Node, id int64) bool {
+	n.mu.RLock()
+	var egress []*Link
+	for _, link := range n.egress[node.Key()] {
+		if !link.removed.Load() {
+			egress = append(egress, link)
+		}
+	}
+	n.mu.RUnlock()
+
+	for _, link := range egress {
+		if !n.send(ctx, link, packet{barrier: id}) {
+			return false
+		}
+	}
+	return true
+}
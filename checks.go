@@ -17,6 +17,16 @@ func (n *Network) checkCycle(from, to string) bool {
 	return cycle
 }
 
+// hasCycles reports whether the Network has any cycles.
+func (n *Network) hasCycles() bool {
+	for _, link := range n.Links() {
+		if n.checkCycle(link.x.Key(), link.y.Key()) {
+			return true
+		}
+	}
+	return false
+}
+
 func dfs(n *Network, root string, callback func(string) bool) {
 	visited := make(map[string]bool)
 	var stack []string
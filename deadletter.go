@@ -0,0 +1,135 @@
+package glow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ErrorPolicy sets what a Node does when its Node function fails for incoming data.
+type ErrorPolicy int
+
+const (
+	// FailOnError stops the Network with the error, which is the default.
+	FailOnError ErrorPolicy = iota
+	// SkipOnError drops the incoming data and keeps going.
+	SkipOnError
+	// DeadLetterOnError sends the incoming data along with the error as FailedData over
+	// the dead-letter Link(s) of the Node and keeps going. See DeadLetter.
+	DeadLetterOnError
+)
+
+// FailedData captures incoming data the Node function of the Node identified by the key failed for.
+// FailedData is sent over dead-letter Link(s), see DeadLetterOnError.
+type FailedData struct {
+	Key  string
+	Data any
+	Err  error
+}
+
+func (f FailedData) Error() string {
+	return fmt.Sprintf("node %s: %v", f.Key, f.Err)
+}
+
+func (f FailedData) Unwrap() error {
+	return f.Err
+}
+
+var failedDataType = reflect.TypeOf(FailedData{})
+
+// OnError sets the error policy for the Node.
+// The policy applies to Node(s) with incoming data, errors of seed-nodes stop the Network.
+// With SkipOnError or DeadLetterOnError, errors are counted in Network.Stats and data is
+// acknowledged with the error (see AckFunc).
+func OnError(p ErrorPolicy) NodeOpt {
+	return func(n *Node) {
+		n.onError = p
+	}
+}
+
+// DeadLetter marks the Link as a dead-letter Link. From-node sends only FailedData over dead-letter Link(s),
+// and regular data is never sent over them. Input type of typed to-node must accept FailedData.
+// See:
+//   - DeadLetterOnError
+func DeadLetter() LinkOpt {
+	return func(l *Link) {
+		l.deadLetter = true
+	}
+}
+
+// divert handles the incoming data the Node function failed for, according to the error policy of the Node.
+// It reports false if the Network must stop with the error.
+func (n *Network) divert(ctx context.Context, node *Node, p packet, err error) bool {
+	switch node.errorPolicy(err) {
+	case SkipOnError:
+		n.log().Warn("Skipped data", "node", node.Key(), "error", err, n.payload(p.data))
+		return true
+	case DeadLetterOnError:
+		n.mu.RLock()
+		var links []*Link
+		for _, link := range n.egress[node.Key()] {
+			if link.deadLetter && !link.removed.Load() {
+				links = append(links, link)
+			}
+		}
+		n.mu.RUnlock()
+
+		if len(links) == 0 {
+			n.log().Warn("No dead-letter route for data", "node", node.Key(), "error", err, n.payload(p.data))
+			return true
+		}
+
+		failed := packet{data: FailedData{Key: node.Key(), Data: p.data, Err: err}, ack: p.ack}
+		for _, link := range links {
+			if !n.send(ctx, link, failed) {
+				break
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// injectKey identifies the source of data injected into the Network.
+const injectKey = "(inject)"
+
+// Inject sends data to the running Node identified by the key, as if the data came over an ingress Link.
+// It allows FailedData to be re-injected into the Node the data failed at:
+//
+//	net.Inject(failed.Key, failed.Data)
+//
+// Data is taken right away, and it is processed along with data coming over the other ingress Link(s) of the Node.
+func (n *Network) Inject(key string, data ...any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	node, ok := n.nodes[key]
+	if !ok {
+		return ErrNodeNotFound
+	}
+	run, ok := n.session.runs[key]
+	if !ok {
+		return ErrNodeNotRunning
+	}
+	if run.seed {
+		return ErrNodeIsSeeding
+	}
+
+	// transient Link feeding the data, it is closed once the data is read
+	link := &Link{
+		x:  &Node{key: injectKey},
+		y:  node,
+		ch: make(chan packet, len(data)),
+	}
+	for _, d := range data {
+		link.ch <- packet{data: d}
+	}
+	close(link.ch)
+	link.closed.Store(true)
+
+	if !run.attach(link) {
+		return ErrNodeNotRunning
+	}
+	return nil
+}
@@ -16,21 +16,41 @@ const tmpl = `strict digraph {
 		[
 			label="{{ nodeProp "label" . }}",
 			style="{{ nodeProp "style" . }}",
-			fillcolor="{{ nodeProp "color" . }}"
+			fillcolor="{{ nodeProp "color" . }}",
+			distributor="{{ nodeProp "distributor" . }}",
+			strategy="{{ nodeProp "strategy" . }}",
+			workers="{{ nodeProp "workers" . }}",
+			ordered="{{ nodeProp "ordered" . }}"
 		];
     {{ end -}}
     {{ range .Links -}}
         "{{ fromNode . }}" -> "{{ toNode . }}"
 		[
 			label="{{ linkProp "label" . }}",
-			color="{{ linkProp "color" . }}"
-			arrowhead="{{ linkProp "arrowhead" . }}"
+			color="{{ linkProp "color" . }}",
+			style="{{ linkProp "style" . }}",
+			penwidth="{{ linkProp "penwidth" . }}",
+			arrowhead="{{ linkProp "arrowhead" . }}",
+			size="{{ linkProp "size" . }}",
+			backpressure="{{ linkProp "backpressure" . }}",
+			weight="{{ linkProp "weight" . }}",
+			deadletter="{{ linkProp "deadletter" . }}",
+			durable="{{ linkProp "durable" . }}"
 		];
     {{ end }}
 }`
 
 // DOT describes the Network.
+// Description includes attributes honored by ParseDOT, therefore the Network can be built back from it.
+// The hot path, followed from a seed-node over the most used Link(s), is highlighted.
 func DOT(n *Network) ([]byte, error) {
+	hot := make(map[*Link]bool)
+	if path, ok := n.hotPath(); ok {
+		for _, link := range path.Links() {
+			hot[link] = true
+		}
+	}
+
 	t := template.New("tmpl")
 	t.Funcs(template.FuncMap{
 		"fromNode": func(link *Link) string {
@@ -50,23 +70,40 @@ func DOT(n *Network) ([]byte, error) {
 		"nodeProp": func(prop string, node *Node) any {
 			switch prop {
 			case "label":
+				if node.workers > 1 {
+					return fmt.Sprintf("%s\n(%s)\n(%d workers)", node.key, node.Uptime(), node.workers)
+				}
 				return fmt.Sprintf("%s\n(%s)", node.key, node.Uptime())
 			case "color":
 				switch {
 				case len(n.Egress(node.Key())) > 0 && node.distributor:
 					// node with egress and distributor mode set
 					return "lightyellow"
+				case len(n.Egress(node.Key())) > 0 && node.router != nil:
+					// node with egress and router mode set
+					return "lightpink"
+				case len(n.Egress(node.Key())) > 0 && node.partition != nil:
+					// node with egress and partitioned mode set
+					return "lightcyan"
 				default:
 					return ""
 				}
 			case "style":
 				switch {
-				case len(n.Egress(node.Key())) > 0 && node.distributor:
-					// node with egress and distributor mode set
+				case len(n.Egress(node.Key())) > 0 && (node.distributor || node.router != nil || node.partition != nil):
+					// node with egress and distributor, router or partitioned mode set
 					return "filled"
 				default:
 					return ""
 				}
+			case "distributor":
+				return node.distributor
+			case "strategy":
+				return node.strategy
+			case "workers":
+				return max(node.workers, 1)
+			case "ordered":
+				return node.ordered
 			default:
 				return ""
 			}
@@ -74,24 +111,55 @@ func DOT(n *Network) ([]byte, error) {
 		"linkProp": func(prop string, link *Link) any {
 			switch prop {
 			case "label":
+				if dropped := link.Dropped(); dropped > 0 {
+					return fmt.Sprintf("%d\n  (%d dropped)\n  (%s)", link.Tally(), dropped, link.Uptime())
+				}
 				return fmt.Sprintf("%d\n  (%s)", link.Tally(), link.Uptime())
 			case "color":
 				switch {
-				case link.paused:
+				case link.paused.Load():
 					return "gray"
-				case link.removed:
+				case link.removed.Load():
 					return "red"
+				case hot[link]:
+					return "orange"
 				default:
 					return "lightblue"
 				}
 			case "arrowhead":
 				switch {
-				case link.paused || link.removed:
+				case link.paused.Load() || link.removed.Load():
 					return "none"
 				default:
 					return "normal"
 				}
-			//case "penwidth":
+			case "size":
+				return link.size
+			case "backpressure":
+				return link.backpressure
+			case "weight":
+				return max(link.weight, 1)
+			case "deadletter":
+				return link.deadLetter
+			case "durable":
+				if link.durable == nil {
+					return ""
+				}
+				return link.durable.dir
+			case "style":
+				switch {
+				case link.deadLetter:
+					return "dashed"
+				default:
+					return "solid"
+				}
+			case "penwidth":
+				switch {
+				case hot[link]:
+					return 3
+				default:
+					return 1
+				}
 			default:
 				return ""
 			}
@@ -0,0 +1,418 @@
+package glow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Durable backs the Link with an append-only log on local disk in the directory, which must be exclusive to the Link.
+// Data is appended to the log before it goes over the Link, and it is committed once the to-node is done with it,
+// whether the Node function succeeded or the data was skipped or dead-lettered as per the error policy of the Node.
+// Data that is not committed, e.g. buffered in the Link when the Network stopped, is delivered first by the next
+// session, including the one of a restarted process.
+//
+// Delivery is at-least-once: data the to-node was done with but not yet committed is delivered again.
+// Distributor logs data only for the durable Link taking it, and the to-node waits for the data to be logged.
+// Durable Link always holds data when full, whatever its backpressure is.
+// Data must be encodable with encoding/gob, and concrete types carried as interface values must be registered
+// with gob.Register.
+func Durable(dir string) LinkOpt {
+	return func(l *Link) {
+		l.durable = &durableLog{dir: dir}
+	}
+}
+
+const (
+	segmentExt   = ".segment"
+	segmentLimit = 64 << 20 // size a segment is rolled at
+	committedLog = "committed"
+	recordHeader = 8 // length and checksum of the record
+)
+
+// durableLog is an append-only log of data in segments, it is safe for concurrent use.
+// Records are identified by offsets, starting at 1, and they are committed in any order.
+type durableLog struct {
+	dir string
+
+	mu        sync.Mutex
+	segments  []int64  // offsets of the first records of the segments, in order
+	seg       *os.File // segment being appended to, the last one
+	segSize   int64
+	next      int64          // offset of the next record to append
+	committed int64          // records up to the offset are committed
+	done      map[int64]bool // offsets committed ahead of the committed records
+	replayTo  int64          // records up to the offset are delivered first by the session
+	commitLog *os.File
+}
+
+type record struct {
+	Data any
+}
+
+// open opens the log in the directory, it recovers appended records and committed offset.
+func (l *durableLog) open() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seg != nil {
+		return nil
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+
+	commitLog, err := os.OpenFile(filepath.Join(l.dir, committedLog), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	var b [8]byte
+	if _, err = commitLog.ReadAt(b[:], 0); err != nil && !errors.Is(err, io.EOF) {
+		commitLog.Close()
+		return err
+	}
+	l.committed = int64(binary.LittleEndian.Uint64(b[:]))
+	l.done = make(map[int64]bool)
+	l.next = l.committed + 1
+
+	if l.segments, err = segments(l.dir); err != nil {
+		commitLog.Close()
+		return err
+	}
+	if len(l.segments) == 0 {
+		l.segments = []int64{l.next}
+	}
+	last := l.segments[len(l.segments)-1]
+
+	seg, err := os.OpenFile(segmentName(l.dir, last), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		commitLog.Close()
+		return err
+	}
+	// count the records of the last segment, a partially written record is dropped
+	count, size, err := scan(seg, func([]byte) bool { return true })
+	if err == nil {
+		err = seg.Truncate(size)
+	}
+	if err == nil {
+		_, err = seg.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		seg.Close()
+		commitLog.Close()
+		return err
+	}
+
+	l.seg = seg
+	l.segSize = size
+	l.next = max(l.next, last+count)
+	l.replayTo = l.next - 1
+	l.commitLog = commitLog
+	return nil
+}
+
+// close closes the log files, the log can be opened again.
+func (l *durableLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seg == nil {
+		return
+	}
+	_ = l.seg.Sync()
+	_ = l.seg.Close()
+	_ = l.commitLog.Close()
+	l.seg = nil
+	l.commitLog = nil
+}
+
+// append adds the data to the log, it returns the offset of the record.
+func (l *durableLog) append(data any) (int64, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, recordHeader))
+	if err := gob.NewEncoder(&buf).Encode(&record{Data: data}); err != nil {
+		return 0, err
+	}
+	b := buf.Bytes()
+	binary.LittleEndian.PutUint32(b[0:], uint32(len(b)-recordHeader))
+	binary.LittleEndian.PutUint32(b[4:], crc32.ChecksumIEEE(b[recordHeader:]))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.seg == nil {
+		return 0, os.ErrClosed
+	}
+	if l.segSize > 0 && l.segSize+int64(len(b)) > segmentLimit {
+		seg, err := os.OpenFile(segmentName(l.dir, l.next), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return 0, err
+		}
+		_ = l.seg.Close()
+		l.seg = seg
+		l.segSize = 0
+		l.segments = append(l.segments, l.next)
+	}
+	if _, err := l.seg.Write(b); err != nil {
+		return 0, err
+	}
+	l.segSize += int64(len(b))
+	offset := l.next
+	l.next++
+	return offset, nil
+}
+
+// commit marks the record at the offset as done, segments holding only committed records are deleted.
+func (l *durableLog) commit(offset int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if offset <= l.committed || l.commitLog == nil {
+		return nil
+	}
+	l.done[offset] = true
+	committed := l.committed
+	for l.done[committed+1] {
+		delete(l.done, committed+1)
+		committed++
+	}
+	if committed == l.committed {
+		return nil
+	}
+	l.committed = committed
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(committed))
+	if _, err := l.commitLog.WriteAt(b[:], 0); err != nil {
+		return err
+	}
+
+	// the last segment is kept to append to
+	for len(l.segments) > 1 && l.segments[1]-1 <= committed {
+		if err := os.Remove(segmentName(l.dir, l.segments[0])); err != nil {
+			return err
+		}
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+// rewind sets the records not committed so far to be delivered first by the session.
+func (l *durableLog) rewind() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.replayTo = l.next - 1
+}
+
+// replay calls f for every record not committed when the session started, in order.
+// It stops early if f returns false.
+func (l *durableLog) replay(f func(offset int64, data any) bool) error {
+	l.mu.Lock()
+	from, to := l.committed+1, l.replayTo
+	segs := slices.Clone(l.segments)
+	l.mu.Unlock()
+
+	for i, start := range segs {
+		if start > to {
+			return nil
+		}
+		if i+1 < len(segs) && segs[i+1] <= from {
+			continue
+		}
+		more, err := l.replaySegment(start, from, to, f)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaySegment replays the records of the segment, it reports whether to go on with the next segment.
+func (l *durableLog) replaySegment(start, from, to int64, f func(offset int64, data any) bool) (bool, error) {
+	seg, err := os.Open(segmentName(l.dir, start))
+	if err != nil {
+		return false, err
+	}
+	defer seg.Close()
+
+	offset := start - 1
+	more := true
+	var decodeErr error
+	_, _, err = scan(seg, func(b []byte) bool {
+		offset++
+		if offset > to {
+			more = false
+			return false
+		}
+		if offset < from || l.isDone(offset) {
+			return true
+		}
+		var r record
+		if decodeErr = gob.NewDecoder(bytes.NewReader(b)).Decode(&r); decodeErr != nil {
+			decodeErr = fmt.Errorf("decoding record %d: %w", offset, decodeErr)
+			return false
+		}
+		more = f(offset, r.Data)
+		return more
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	return more && err == nil, err
+}
+
+func (l *durableLog) isDone(offset int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return offset <= l.committed || l.done[offset]
+}
+
+// scan calls f with every intact record of the segment until f returns false.
+// It returns the count of the records seen and the size they take.
+func scan(seg *os.File, f func([]byte) bool) (int64, int64, error) {
+	if _, err := seg.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	r := bufio.NewReader(seg)
+	var count, size int64
+	var header [recordHeader]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return count, size, nil
+			}
+			return count, size, err
+		}
+		n := binary.LittleEndian.Uint32(header[0:])
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return count, size, nil
+			}
+			return count, size, err
+		}
+		if crc32.ChecksumIEEE(b) != binary.LittleEndian.Uint32(header[4:]) {
+			return count, size, nil
+		}
+		count++
+		size += int64(recordHeader + n)
+		if !f(b) {
+			return count, size, nil
+		}
+	}
+}
+
+// segments returns the offsets of the first records of the segments in the directory, in order.
+func segments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var starts []int64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		start, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	slices.Sort(starts)
+	return starts, nil
+}
+
+func segmentName(dir string, start int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", start, segmentExt))
+}
+
+// replay delivers data the durable Link had not committed when the session started, ahead of data coming over
+// the Link, to the channel. The channel is closed once the Link is closed or ctx is done.
+func (n *Network) replay(ctx context.Context, link *Link, in chan<- packet) {
+	defer close(in)
+
+	err := link.durable.replay(func(offset int64, data any) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case in <- packet{data: data, offset: offset}:
+			return true
+		}
+	})
+	if err != nil {
+		n.log().Error("Replaying durable link failed", linkAttr(link), "error", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p, ok := <-link.ch:
+			if !ok {
+				return
+			}
+			if p.logging != nil {
+				// data is not processed before it is logged
+				select {
+				case <-ctx.Done():
+					return
+				case <-p.logging.done:
+				}
+				p.offset, p.logging = p.logging.offset, nil
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in <- p:
+			}
+		}
+	}
+}
+
+// logged appends the data to the log of durable Link, the returned packet carries the offset of the record.
+// Data that can't be logged is sent as is.
+func (n *Network) logged(link *Link, p packet) packet {
+	if link.durable == nil || p.barrier != 0 {
+		return p
+	}
+	offset, err := link.durable.append(p.data)
+	if err != nil {
+		n.log().Error("Data not logged for durable link", "node", link.x.Key(), linkAttr(link), "error", err, n.payload(p.data))
+		return p
+	}
+	p.offset = offset
+	return p
+}
+
+// pendingLog is the record of data handed over durable Link ahead of logging it, as distributor offers data
+// to many Link(s) and only the one taking it logs it. Offset is set once done is closed.
+type pendingLog struct {
+	done   chan struct{}
+	offset int64
+}
+
+// logPending logs the data handed over durable Link ahead of logging it.
+func (n *Network) logPending(link *Link, p packet, pending *pendingLog) {
+	pending.offset = n.logged(link, p).offset
+	close(pending.done)
+}
+
+// commit marks the data at the offset of the log of durable Link as done.
+func (n *Network) commit(link *Link, offset int64) {
+	if err := link.durable.commit(offset); err != nil {
+		n.log().Error("Data not committed for durable link", "node", link.y.Key(), linkAttr(link), "offset", offset, "error", err)
+	}
+}
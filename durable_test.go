@@ -0,0 +1,289 @@
+package glow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDurableLogRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, seg string, sizes []int64)
+		want    []int64 // offsets replayed after recovery
+	}{
+		{
+			name:    "intact",
+			corrupt: func(*testing.T, string, []int64) {},
+			want:    []int64{1, 2, 3},
+		},
+		{
+			name: "partial header",
+			corrupt: func(t *testing.T, seg string, sizes []int64) {
+				appendBytes(t, seg, []byte{1, 2, 3})
+			},
+			want: []int64{1, 2, 3},
+		},
+		{
+			name: "partial record",
+			corrupt: func(t *testing.T, seg string, sizes []int64) {
+				if err := os.Truncate(seg, sizes[0]+sizes[1]+sizes[2]-1); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []int64{1, 2},
+		},
+		{
+			name: "checksum mismatch",
+			corrupt: func(t *testing.T, seg string, sizes []int64) {
+				flipByte(t, seg, sizes[0]+sizes[1]+sizes[2]-1)
+			},
+			want: []int64{1, 2},
+		},
+		{
+			name: "checksum mismatch ahead of intact records",
+			corrupt: func(t *testing.T, seg string, sizes []int64) {
+				flipByte(t, seg, sizes[0]+recordHeader)
+			},
+			want: []int64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l := &durableLog{dir: dir}
+			if err := l.open(); err != nil {
+				t.Fatal(err)
+			}
+			var sizes []int64
+			for i := 1; i <= 3; i++ {
+				size := l.segSize
+				if _, err := l.append(i); err != nil {
+					t.Fatal(err)
+				}
+				sizes = append(sizes, l.segSize-size)
+			}
+			l.close()
+
+			tt.corrupt(t, segmentName(dir, 1), sizes)
+
+			l = &durableLog{dir: dir}
+			if err := l.open(); err != nil {
+				t.Fatal(err)
+			}
+			defer l.close()
+			if got := replayed(t, l); !slices.Equal(got, tt.want) {
+				t.Errorf("replayed %v, want %v", got, tt.want)
+			}
+
+			// records appended after recovery follow the intact ones
+			next := len(tt.want) + 1
+			offset, err := l.append(next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := int64(next); offset != want {
+				t.Errorf("appended at %d, want %d", offset, want)
+			}
+			l.rewind()
+			if got, want := replayed(t, l), append(tt.want, offset); !slices.Equal(got, want) {
+				t.Errorf("replayed %v after append, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDurableLogCommit(t *testing.T) {
+	dir := t.TempDir()
+	l := &durableLog{dir: dir}
+	if err := l.open(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		if _, err := l.append(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// records appended in the session are not replayed until rewound
+	if got := replayed(t, l); len(got) != 0 {
+		t.Errorf("replayed %v, want none", got)
+	}
+	l.rewind()
+	if got, want := replayed(t, l), []int64{1, 2, 3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+
+	// committed out of order
+	for _, offset := range []int64{2, 4, 1} {
+		if err := l.commit(offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if l.committed != 2 {
+		t.Errorf("committed %d, want 2", l.committed)
+	}
+	if got, want := replayed(t, l), []int64{3, 5}; !slices.Equal(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+	l.close()
+
+	// records committed ahead of the committed offset are delivered again by the next process
+	l = &durableLog{dir: dir}
+	if err := l.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer l.close()
+	if got, want := replayed(t, l), []int64{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("replayed %v after reopen, want %v", got, want)
+	}
+	if err := l.commit(1); err != nil {
+		t.Fatal(err)
+	}
+	if l.committed != 2 {
+		t.Errorf("committed %d after committing again, want 2", l.committed)
+	}
+}
+
+func TestDurableLogReplayStops(t *testing.T) {
+	l := &durableLog{dir: t.TempDir()}
+	if err := l.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer l.close()
+	for i := 1; i <= 3; i++ {
+		if _, err := l.append(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.rewind()
+
+	var got []any
+	err := l.replay(func(offset int64, data any) bool {
+		got = append(got, data)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []any{1, 2}; !slices.Equal(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestDurableDistributor(t *testing.T) {
+	const total = 200
+	dir := t.TempDir()
+	net := New()
+	var seeded atomic.Int64
+	var mu sync.Mutex
+	received := make(map[int64]int)
+	if _, err := net.AddNode(Key("seed"), Distributor(), BasicFunc(func(ctx context.Context, _ any) (any, error) {
+		if seeded.Load() == total {
+			return nil, ErrSeedingDone
+		}
+		return seeded.Add(1), nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := net.AddNode(Key(key), BasicFunc(func(ctx context.Context, data any) (any, error) {
+			mu.Lock()
+			received[data.(int64)]++
+			mu.Unlock()
+			return nil, nil
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// c is an in-memory Link along with the durable ones
+	for _, key := range []string{"a", "b"} {
+		if err := net.AddLink("seed", key, Size(1), Durable(filepath.Join(dir, key))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := net.AddLink("seed", "c", Size(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := net.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int64(1); i <= total; i++ {
+		if received[i] != 1 {
+			t.Errorf("%d received %d times", i, received[i])
+		}
+	}
+	logged := 0
+	for _, key := range []string{"a", "b"} {
+		link, err := net.Link("seed", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := link.durable
+		// data is logged only for the Link it went over
+		if got := l.next - 1; got != int64(link.Tally()) {
+			t.Errorf("link to %s logged %d, transmitted %d", key, got, link.Tally())
+		}
+		if l.committed != l.next-1 {
+			t.Errorf("link to %s committed %d of %d", key, l.committed, l.next-1)
+		}
+		logged += link.Tally()
+		l.close()
+	}
+	if logged == 0 {
+		t.Error("no data went over durable links")
+	}
+}
+
+// replayed returns the offsets replayed by the log, checking the data appended at the offset is the offset.
+func replayed(t *testing.T, l *durableLog) []int64 {
+	t.Helper()
+	var offsets []int64
+	err := l.replay(func(offset int64, data any) bool {
+		if data != int(offset) {
+			t.Errorf("replayed %v at %d", data, offset)
+		}
+		offsets = append(offsets, offset)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return offsets
+}
+
+func appendBytes(t *testing.T, name string, b []byte) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func flipByte(t *testing.T, name string, at int64) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var b [1]byte
+	if _, err = f.ReadAt(b[:], at); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 0xff
+	if _, err = f.WriteAt(b[:], at); err != nil {
+		t.Fatal(err)
+	}
+}
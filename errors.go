@@ -5,6 +5,7 @@ import "errors"
 var (
 	ErrEmptyNetwork       = errors.New("network is empty")
 	ErrNetworkNeedPurging = errors.New("network needs purging")
+	ErrInvalidDOT         = errors.New("invalid DOT")
 
 	ErrNodeNotFound        = errors.New("node not found")
 	ErrBadNodeKey          = errors.New("bad node key")
@@ -12,12 +13,18 @@ var (
 	ErrNodeIsConnected     = errors.New("node is connected")
 	ErrSeedingDone         = errors.New("seeding is done")
 	ErrNodeGoingAway       = errors.New("node is going away")
+	ErrNodeNotRunning      = errors.New("node is not running")
+	ErrNodeIsSeeding       = errors.New("node is seeding")
 	ErrIsolatedNodeFound   = errors.New("isolated node found")
 	ErrNodeFunctionMissing = errors.New("node function missing")
 	ErrTooManyNodeFunction = errors.New("too many node functions")
+	ErrTooManyNodeModes    = errors.New("too many node modes")
+	ErrNodePanicked        = errors.New("node panicked")
 
 	ErrLinkNotFound      = errors.New("link not found")
 	ErrLinkAlreadyExists = errors.New("link already exists")
 	ErrCyclesNotAllowed  = errors.New("cycles not allowed")
 	ErrLinkAlreadyPaused = errors.New("link already paused")
+	ErrTypeMismatch      = errors.New("type mismatch")
+	ErrDataDropped       = errors.New("data dropped")
 )
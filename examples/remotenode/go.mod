@@ -0,0 +1,12 @@
+module remotenode
+
+go 1.22.3
+
+replace github.com/lnashier/glow => ../../../glow
+
+require github.com/lnashier/glow v0.0.0
+
+require (
+	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
+	golang.org/x/sync v0.7.0 // indirect
+)
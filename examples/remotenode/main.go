@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/lnashier/glow/flow"
+	"github.com/lnashier/glow/remote"
+	"net"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Remote process is served over loopback listener in the same process.
+	// In practice, it runs in another process, possibly on another machine.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("err:", err)
+		return
+	}
+	go remote.Serve(ctx, l, func(ctx context.Context, in any, emit func(any)) error {
+		emit(fmt.Sprintf("%x", sha256.Sum256([]byte(in.(string)))))
+		return nil
+	})
+
+	err = flow.New().
+		Step(
+			flow.StepKey("generator"),
+			flow.Read(func(ctx context.Context, emit func(out any)) error {
+				for i := range 10 {
+					emit(fmt.Sprintf("data-%d", i))
+				}
+				return nil
+			}),
+			flow.Distributor(),
+		).
+		Step(
+			flow.StepKey("hasher"),
+			flow.Map(remote.Func("tcp", l.Addr().String())),
+			flow.Replicas(2),
+			flow.Connection("generator"),
+		).
+		Step(
+			flow.StepKey("printer"),
+			flow.Capture(func(ctx context.Context, in any) error {
+				fmt.Println(in)
+				return nil
+			}),
+			flow.Connection("hasher"),
+		).
+		Draw("bin/network.gv").
+		Run(ctx).
+		Draw("bin/network-tally.gv").
+		Error()
+
+	if err != nil {
+		fmt.Println("err:", err)
+	}
+}
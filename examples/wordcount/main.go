@@ -10,19 +10,16 @@ import (
 )
 
 func main() {
-	err := flow.Sequential( /*glow.Verbose()*/ ).
-		Read(func(ctx context.Context, emit func(out any)) error {
-			return plug.ReadFile("test.txt", emit)
-		}).
-		Map(func(ctx context.Context, in any, emit func(any)) error {
-			// Receiver function (method) cannot have type parameters.
-			// This is the cleanest way at this moment.
-			// https://go.googlesource.com/proposal/+/refs/heads/master/design/43651-type-parameters.md#no-parameterized-methods
-			plug.Tokenize(ctx, in.(string), emit)
-			return nil
-		}, flow.Distributor(), flow.StepKey("tokenizer")).
-		Filter(func(in any) bool {
-			return strings.HasPrefix(in.(string), "test")
+	seq := flow.ReadOf(flow.Sequential( /*glow.Verbose()*/ ), func(ctx context.Context, emit func(string)) error {
+		return plug.ReadFile("test.txt", emit)
+	})
+
+	err := flow.MapOf(seq, func(ctx context.Context, in string, emit func(string)) error {
+		plug.Tokenize(ctx, in, emit)
+		return nil
+	}, flow.Distributor(), flow.StepKey("tokenizer")).
+		Filter(func(in string) bool {
+			return strings.HasPrefix(in, "test")
 		}, flow.Replicas(5)).
 		Count(func(num int) {
 			fmt.Println("Count:", num)
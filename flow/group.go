@@ -0,0 +1,127 @@
+package flow
+
+import (
+	"context"
+	"github.com/lnashier/glow"
+	"golang.org/x/exp/maps"
+	"slices"
+	"sync"
+)
+
+// KeyValue is a result of GroupByKey or ReduceByKey step.
+type KeyValue struct {
+	Key   string
+	Value any
+}
+
+// GroupByKey groups data by the key taken from the data with the key extractor.
+// At the end of the stream, every group is emitted downstream as KeyValue holding the data of the group as []any,
+// in the order of arrival, and all the groups are provided to the callback, if any.
+// With Replicas, data is partitioned among the replicas by key, so each key is handled by exactly one replica.
+// See:
+//   - ReduceByKey
+func GroupByKey(kf func(in any) string, cb func(groups map[string][]any)) StepOpt {
+	return byKey(GroupStep, kf, func(acc any, ok bool, in any) any {
+		if !ok {
+			return []any{in}
+		}
+		return append(acc.([]any), in)
+	}, func(values map[string]any) {
+		if cb == nil {
+			return
+		}
+		groups := make(map[string][]any, len(values))
+		for k, v := range values {
+			groups[k] = v.([]any)
+		}
+		cb(groups)
+	})
+}
+
+// ReduceByKey combines data by the key taken from the data with the key extractor.
+// The first data point for a key is the initial value, and the combiner folds subsequent data points into it.
+// At the end of the stream, every combined value is emitted downstream as KeyValue, and all the combined values
+// are provided to the callback, if any.
+// With Replicas, data is partitioned among the replicas by key, so each key is handled by exactly one replica.
+// See:
+//   - GroupByKey
+func ReduceByKey(kf func(in any) string, rf func(acc any, in any) any, cb func(values map[string]any)) StepOpt {
+	return byKey(ReduceStep, kf, func(acc any, ok bool, in any) any {
+		if !ok {
+			return in
+		}
+		return rf(acc, in)
+	}, func(values map[string]any) {
+		if cb != nil {
+			cb(values)
+		}
+	})
+}
+
+// shard holds the values of the keys handled by a replica.
+type shard struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// byKey makes a step keeping a value per key, folding data into it with the add function.
+func byKey(kind StepKind, kf func(any) string, add func(acc any, ok bool, in any) any, cb func(map[string]any)) StepOpt {
+	return func(o *stepOpts) {
+		o.kind = kind
+		o.partition = kf
+
+		var once sync.Once
+		var shards []*shard
+		init := func() {
+			once.Do(func() {
+				shards = make([]*shard, max(o.replicas, 1))
+				for i := range shards {
+					shards[i] = &shard{values: make(map[string]any)}
+				}
+			})
+		}
+
+		o.callback = func() {
+			init()
+			values := make(map[string]any)
+			for _, s := range shards {
+				maps.Copy(values, s.values)
+			}
+			cb(values)
+		}
+
+		o.sf = func(ctx context.Context, in any, _ func(any)) error {
+			init()
+			key := kf(in)
+			s := shards[glow.KeyPartition(key, len(shards))]
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			acc, ok := s.values[key]
+			s.values[key] = add(acc, ok, in)
+			return nil
+		}
+
+		o.flush = func(ctx context.Context, replica int, emit func(any)) error {
+			init()
+			s := shards[replica%len(shards)]
+			s.mu.Lock()
+			keys := maps.Keys(s.values)
+			slices.Sort(keys)
+			values := make([]KeyValue, 0, len(keys))
+			for _, key := range keys {
+				values = append(values, KeyValue{Key: key, Value: s.values[key]})
+			}
+			s.mu.Unlock()
+
+			for _, kv := range values {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					emit(kv)
+				}
+			}
+			return nil
+		}
+	}
+}
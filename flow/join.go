@@ -0,0 +1,281 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"github.com/lnashier/glow"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Joined is a result of Join step, a pair of data with the same key from the joined Steps.
+type Joined struct {
+	Key   string
+	Left  any
+	Right any // nil for left data without a match, see LeftJoin
+}
+
+// Join joins data of the left and right Steps, identified by their keys, by the keys taken from the data with the
+// key extractors. Data of each side is kept, and every data point coming in is emitted downstream as Joined with
+// every kept data point of the other side with the same key, in the order of arrival. This is an inner join,
+// see LeftJoin for left join.
+// Data is kept until the end of the stream, unless bound by JoinWindow or JoinBuffer.
+// Join step gets data from the joined Steps only, so it can't have other connections, including dead-letter ones,
+// and it must have a key.
+// With Replicas, data is partitioned among the replicas by key, so each key is handled by exactly one replica.
+// See:
+//   - LeftJoin
+//   - JoinWindow
+//   - JoinBuffer
+func Join(left, right string, lk, rk func(in any) string) StepOpt {
+	return func(o *stepOpts) {
+		o.kind = JoinStep
+		jo := o.joinOpts()
+		jo.left, jo.right, jo.lk, jo.rk = left, right, lk, rk
+		o.partition = func(in any) string {
+			// data of other than the joined steps is rejected by the Join step
+			j, _ := in.(joined)
+			return j.key
+		}
+
+		var once sync.Once
+		var shards []*joinShard
+		init := func() {
+			once.Do(func() {
+				shards = make([]*joinShard, max(o.replicas, 1))
+				for i := range shards {
+					shards[i] = &joinShard{}
+				}
+			})
+		}
+
+		o.sf = func(ctx context.Context, in any, emit func(any)) error {
+			init()
+			j, ok := in.(joined)
+			if !ok {
+				return fmt.Errorf("%w: %s expected data of joined steps, got %T", glow.ErrTypeMismatch, o.key, in)
+			}
+			at := time.Now()
+			if o.join.ts != nil {
+				at = o.join.ts(j.data)
+			}
+			s := shards[glow.KeyPartition(j.key, len(shards))]
+			s.mu.Lock()
+			out := s.add(j, at, o.join)
+			s.mu.Unlock()
+
+			for _, v := range out {
+				emit(v)
+			}
+			return nil
+		}
+
+		o.flush = func(ctx context.Context, replica int, emit func(any)) error {
+			init()
+			s := shards[replica%len(shards)]
+			s.mu.Lock()
+			var out []any
+			if o.join.outer {
+				for _, e := range s.sides[0].entries {
+					if !e.matched {
+						out = append(out, Joined{Key: e.key, Left: e.data})
+					}
+				}
+			}
+			s.sides = [2]joinBuffer{}
+			s.mu.Unlock()
+
+			for _, v := range out {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					emit(v)
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// LeftJoin makes a Join step emit left data that found no match as Joined without Right,
+// once the data is let go as per JoinWindow or JoinBuffer, or at the end of the stream.
+func LeftJoin() StepOpt {
+	return func(o *stepOpts) {
+		o.joinOpts().outer = true
+	}
+}
+
+// JoinWindow makes a Join step join only data with times within the duration of each other, and keep data
+// until the latest time seen of both sides passes its time by the duration. Times are the event times taken from the data of
+// either side with the timestamp extractor, as for window steps. With nil extractor, times are the arrival times
+// at the Join step, so data joins by when it arrives rather than when it happened, e.g. data replayed by a
+// durable Link arrives late and misses its matches. Data is let go as data arrives.
+// The duration must not be negative.
+// See:
+//   - TumblingWindow
+func JoinWindow(d time.Duration, ts func(in any) time.Time) StepOpt {
+	return func(o *stepOpts) {
+		o.joinOpts().window = d
+		o.join.ts = ts
+	}
+}
+
+// JoinBuffer makes a Join step keep up to n data points of each side, per replica.
+// The data point with the earliest time is let go to make room, see JoinWindow for the time of data.
+// It must not be negative.
+func JoinBuffer(n int) StepOpt {
+	return func(o *stepOpts) {
+		o.joinOpts().buffer = n
+	}
+}
+
+type joinOpts struct {
+	left   string
+	right  string
+	lk     func(any) string
+	rk     func(any) string
+	outer  bool                // left join
+	window time.Duration       // how far apart times of joined data can be, zero keeps data until the end of the stream
+	ts     func(any) time.Time // event time of data, nil for arrival time
+	buffer int                 // how many data points of each side are kept, zero keeps all
+}
+
+func (o *stepOpts) joinOpts() *joinOpts {
+	if o.join == nil {
+		o.join = &joinOpts{}
+	}
+	return o.join
+}
+
+// joined is data of a joined Step on its way to the Join step.
+type joined struct {
+	right bool
+	key   string
+	data  any
+}
+
+type joinEntry struct {
+	key     string
+	data    any
+	at      time.Time
+	matched bool
+}
+
+// joinBuffer holds data of a side in the order of time.
+type joinBuffer struct {
+	entries []*joinEntry
+	byKey   map[string][]*joinEntry
+}
+
+func (b *joinBuffer) push(e *joinEntry) {
+	if b.byKey == nil {
+		b.byKey = make(map[string][]*joinEntry)
+	}
+	i := len(b.entries)
+	for i > 0 && b.entries[i-1].at.After(e.at) {
+		i--
+	}
+	b.entries = slices.Insert(b.entries, i, e)
+	b.byKey[e.key] = append(b.byKey[e.key], e)
+}
+
+// pop lets go of the data with the earliest time.
+func (b *joinBuffer) pop() *joinEntry {
+	e := b.entries[0]
+	b.entries[0] = nil
+	b.entries = b.entries[1:]
+	if kept := slices.DeleteFunc(b.byKey[e.key], func(k *joinEntry) bool { return k == e }); len(kept) > 0 {
+		b.byKey[e.key] = kept
+	} else {
+		delete(b.byKey, e.key)
+	}
+	return e
+}
+
+// joinShard holds the data of the keys handled by a replica.
+type joinShard struct {
+	mu     sync.Mutex
+	sides  [2]joinBuffer // left and right
+	latest [2]time.Time  // latest time seen of each side
+}
+
+// add joins the data of the time with the kept data of the other side, and keeps it. It returns the data to emit.
+// Must be called with joinShard.mu held.
+func (s *joinShard) add(j joined, at time.Time, o *joinOpts) []any {
+	var out []any
+	// let go the left data, it is emitted on its own for left join
+	let := func(side int, e *joinEntry) {
+		if side == 0 && o.outer && !e.matched {
+			out = append(out, Joined{Key: e.key, Left: e.data})
+		}
+	}
+
+	side, other := 0, 1
+	if j.right {
+		side, other = 1, 0
+	}
+	if at.After(s.latest[side]) {
+		s.latest[side] = at
+	}
+	e := &joinEntry{key: j.key, data: j.data, at: at}
+	for _, m := range s.sides[other].byKey[j.key] {
+		if o.window > 0 && (at.Sub(m.at) >= o.window || m.at.Sub(at) >= o.window) {
+			continue
+		}
+		m.matched = true
+		e.matched = true
+		if j.right {
+			out = append(out, Joined{Key: j.key, Left: m.data, Right: j.data})
+		} else {
+			out = append(out, Joined{Key: j.key, Left: j.data, Right: m.data})
+		}
+	}
+
+	b := &s.sides[side]
+	b.push(e)
+	if o.buffer > 0 && len(b.entries) > o.buffer {
+		let(side, b.pop())
+	}
+
+	if o.window > 0 {
+		// with event time, a side running ahead of the other must not let go data the other side is yet to match
+		until := at
+		if o.ts != nil {
+			until = s.latest[0]
+			if s.latest[1].Before(until) {
+				until = s.latest[1]
+			}
+		}
+		for side := range s.sides {
+			b := &s.sides[side]
+			for len(b.entries) > 0 && until.Sub(b.entries[0].at) >= o.window {
+				let(side, b.pop())
+			}
+		}
+	}
+	return out
+}
+
+// joinSides makes the Steps feeding the Join step with the data of the joined Steps, tagged with side and key.
+func joinSides(o *stepOpts) []*stepOpts {
+	var sides []*stepOpts
+	for i, x := range []string{o.join.left, o.join.right} {
+		right := i == 1
+		kf, name := o.join.lk, "left"
+		if right {
+			kf, name = o.join.rk, "right"
+		}
+		sides = append(sides, &stepOpts{
+			kind: MapStep,
+			key:  o.key + "-" + name,
+			sf: func(_ context.Context, in any, emit func(any)) error {
+				emit(joined{right: right, key: kf(in), data: in})
+				return nil
+			},
+			connections: []string{x},
+		})
+	}
+	return sides
+}
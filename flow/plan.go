@@ -74,6 +74,38 @@ func (p *Plan) build() {
 
 		steps := make(map[string][]*Step)
 
+		// join steps get data over side steps tagging the data of the joined steps
+		for _, opts := range slices.Clone(p.opts) {
+			if opts.kind != JoinStep {
+				continue
+			}
+			if len(opts.key) == 0 {
+				p.appendError(fmt.Errorf("%s step without key", opts.kind))
+				continue
+			}
+			if len(opts.connections) > 0 || len(opts.deadLetters) > 0 {
+				p.appendError(fmt.Errorf("%s connecting to other than joined steps", opts.key))
+				continue
+			}
+			sides := joinSides(opts)
+			for _, side := range sides {
+				opts.connections = append(opts.connections, side.key)
+			}
+			p.opts = append(p.opts, sides...)
+		}
+
+		// steps partitioning data among their replicas, and the steps connected downstream of each step
+		partitions := make(map[string]func(any) string)
+		downstream := make(map[string][]string)
+		for _, opts := range p.opts {
+			if opts.partition != nil && opts.replicas > 1 {
+				partitions[opts.key] = opts.partition
+			}
+			for _, x := range opts.connections {
+				downstream[x] = append(downstream[x], opts.key)
+			}
+		}
+
 		// make nodes
 		for _, opts := range p.opts {
 			if opts.replicas < 1 {
@@ -83,6 +115,15 @@ func (p *Plan) build() {
 			if slices.Contains(linearKinds, opts.kind) && opts.replicas != 1 {
 				p.appendError(fmt.Errorf("%s step concurrency != 1", opts.kind))
 			}
+			if opts.err != nil {
+				p.appendError(fmt.Errorf("%s %w", opts.key, opts.err))
+			}
+			if opts.kind == JoinStep && (opts.join.window < 0 || opts.join.buffer < 0) {
+				p.appendError(fmt.Errorf("%s join window %s and buffer %d must not be negative", opts.key, opts.join.window, opts.join.buffer))
+			}
+			if opts.kind == WindowStep && (opts.watermark < 0 || opts.lateness < 0) {
+				p.appendError(fmt.Errorf("%s watermark %s and allowed lateness %s must not be negative", opts.key, opts.watermark, opts.lateness))
+			}
 
 			var replicas []*Step
 
@@ -98,6 +139,36 @@ func (p *Plan) build() {
 				if opts.distributor {
 					nodeOpts = append(nodeOpts, glow.Distributor())
 				}
+				if opts.partitioner != nil {
+					nodeOpts = append(nodeOpts, glow.Partition(opts.partitioner))
+				}
+				if opts.ack != nil {
+					nodeOpts = append(nodeOpts, glow.AckFunc(opts.ack))
+				}
+				if opts.flush != nil {
+					nodeOpts = append(nodeOpts, glow.FlushFunc(func(ctx context.Context, emit func(any)) error {
+						return opts.flush(ctx, i, emit)
+					}))
+				}
+				nodeOpts = append(nodeOpts, opts.nodeOpts...)
+				switch rf := opts.router; {
+				case rf != nil:
+					nodeOpts = append(nodeOpts, glow.Router(routeReplicas(steps, partitions, rf)))
+				case partitioned(downstream[opts.key], partitions):
+					if opts.distributor {
+						p.appendError(fmt.Errorf("%s distributing to partitioned step", opts.key))
+						break
+					}
+					if opts.partitioner != nil {
+						p.appendError(fmt.Errorf("%s partitioning to partitioned step", opts.key))
+						break
+					}
+					// data goes to all the next Steps, and to one replica of the partitioned ones
+					next := downstream[opts.key]
+					nodeOpts = append(nodeOpts, glow.Router(routeReplicas(steps, partitions, func(any) []string {
+						return next
+					})))
+				}
 				nodeID, err := p.net.AddNode(nodeOpts...)
 				p.appendError(err)
 				if err == nil {
@@ -128,11 +199,48 @@ func (p *Plan) build() {
 						p.appendError(p.net.AddLink(xReplica.id, yReplica.id))
 					}
 				}
+				for _, x := range y.deadLetters {
+					xReplicas := steps[x]
+					if len(xReplicas) < 1 {
+						p.appendError(fmt.Errorf("%s connecting to unknown %s", y.key, x))
+						continue
+					}
+					for _, xReplica := range xReplicas {
+						p.appendError(p.net.AddLink(xReplica.id, yReplica.id, glow.DeadLetter()))
+					}
+				}
 			}
 		}
 	})
 }
 
+// routeReplicas turns a router function returning Step keys into a router function returning
+// keys of the replicas of those Steps. Data goes to one replica of the Steps partitioning data by key.
+func routeReplicas(steps map[string][]*Step, partitions map[string]func(any) string, rf func(any) []string) func(any) []string {
+	return func(in any) []string {
+		var keys []string
+		for _, key := range rf(in) {
+			replicas := steps[key]
+			if kf, ok := partitions[key]; ok && len(replicas) > 1 {
+				keys = append(keys, replicas[glow.KeyPartition(kf(in), len(replicas))].id)
+				continue
+			}
+			for _, replica := range replicas {
+				keys = append(keys, replica.id)
+			}
+		}
+		return keys
+	}
+}
+
+// partitioned reports whether any of the Steps partitions data among its replicas.
+func partitioned(keys []string, partitions map[string]func(any) string) bool {
+	return slices.ContainsFunc(keys, func(key string) bool {
+		_, ok := partitions[key]
+		return ok
+	})
+}
+
 func (p *Plan) appendError(err error) {
 	if err != nil {
 		if p.err != nil {
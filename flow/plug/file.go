@@ -56,8 +56,8 @@ func StepReadFile(ctx context.Context, name string, len int, exact bool, emit fu
 	}
 }
 
-// ReadFile read the entire file and calls provided emit function.
-func ReadFile(name string, emit func(data any)) error {
+// ReadFile read the entire file and calls provided emit function with its content.
+func ReadFile(name string, emit func(data string)) error {
 	data, err := os.ReadFile(name)
 	if err != nil {
 		return err
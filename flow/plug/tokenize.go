@@ -5,7 +5,8 @@ import (
 	"strings"
 )
 
-func Tokenize(ctx context.Context, in string, emit func(any)) {
+// Tokenize splits the string into alphanumeric tokens and calls provided emit function for each of them.
+func Tokenize(ctx context.Context, in string, emit func(token string)) {
 	for _, s := range strings.FieldsFunc(in, func(r rune) bool {
 		return !('A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' || '0' <= r && r <= '9')
 	}) {
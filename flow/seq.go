@@ -61,6 +61,31 @@ func (s *Seq) Count(cb func(num int), opt ...StepOpt) *Seq {
 	return s
 }
 
+func (s *Seq) GroupByKey(kf func(in any) string, cb func(groups map[string][]any), opt ...StepOpt) *Seq {
+	s.step(GroupStep, append(opt, GroupByKey(kf, cb))...)
+	return s
+}
+
+func (s *Seq) ReduceByKey(kf func(in any) string, rf func(acc any, in any) any, cb func(values map[string]any), opt ...StepOpt) *Seq {
+	s.step(ReduceStep, append(opt, ReduceByKey(kf, rf, cb))...)
+	return s
+}
+
+func (s *Seq) TumblingWindow(size time.Duration, ts func(in any) time.Time, opt ...StepOpt) *Seq {
+	s.step(WindowStep, append(opt, TumblingWindow(size, ts))...)
+	return s
+}
+
+func (s *Seq) SlidingWindow(size, slide time.Duration, ts func(in any) time.Time, opt ...StepOpt) *Seq {
+	s.step(WindowStep, append(opt, SlidingWindow(size, slide, ts))...)
+	return s
+}
+
+func (s *Seq) SessionWindow(gap time.Duration, ts func(in any) time.Time, opt ...StepOpt) *Seq {
+	s.step(WindowStep, append(opt, SessionWindow(gap, ts))...)
+	return s
+}
+
 func (s *Seq) Run(ctx context.Context) *Seq {
 	s.plan.Run(ctx)
 	return s
@@ -93,7 +118,11 @@ func (s *Seq) step(kind StepKind, opt ...StepOpt) *Seq {
 	if len(key) == 0 {
 		key = fmt.Sprintf("%s-%s", keyPart, kind)
 	}
-	s.plan.Step(append(opt, StepKey(key), Connection(s.preStep))...)
+	opt = append(opt, StepKey(key))
+	if len(s.preStep) > 0 {
+		opt = append(opt, Connection(s.preStep))
+	}
+	s.plan.Step(opt...)
 	s.preStep = key
 	return s
 }
@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"context"
+	"github.com/lnashier/glow"
+	"slices"
+	"time"
+)
+
+// SeqOf is a typed view of Seq where the last Step emits data of type T.
+// Steps that keep the data type are available as methods, while steps that
+// change the data type (e.g. MapOf) are free functions, as methods cannot have type parameters.
+// SeqOf builds the same Plan as Seq, see Seq for the untyped variant.
+// Typed steps fail for data that is not of their type with glow.ErrTypeMismatch, see OnError.
+type SeqOf[T any] struct {
+	seq *Seq
+}
+
+// ReadOf adds a typed Read step to the Seq.
+// See:
+//   - Seq.Read
+func ReadOf[T any](s *Seq, rf func(ctx context.Context, emit func(T)) error, opt ...StepOpt) *SeqOf[T] {
+	s.Read(func(ctx context.Context, emit func(any)) error {
+		return rf(ctx, func(out T) {
+			emit(out)
+		})
+	}, opt...)
+	return &SeqOf[T]{seq: s}
+}
+
+// MapOf adds a typed Map step to the Seq, turning data of type A into data of type B.
+// See:
+//   - Seq.Map
+func MapOf[A, B any](s *SeqOf[A], mf func(ctx context.Context, in A, emit func(B)) error, opt ...StepOpt) *SeqOf[B] {
+	s.seq.Map(func(ctx context.Context, in any, emit func(any)) error {
+		v, err := glow.Cast[A](in)
+		if err != nil {
+			return err
+		}
+		return mf(ctx, v, func(out B) {
+			emit(out)
+		})
+	}, opt...)
+	return &SeqOf[B]{seq: s.seq}
+}
+
+// Peek adds a typed Peek step to the Seq.
+// See:
+//   - Seq.Peek
+func (s *SeqOf[T]) Peek(pf func(in T), opt ...StepOpt) *SeqOf[T] {
+	s.seq.step(PeekStep, append(slices.Clip(opt), checked[T](Peek(func(in any) {
+		v, _ := glow.Cast[T](in)
+		pf(v)
+	})))...)
+	return s
+}
+
+// Combine adds a Combine step to the Seq.
+// See:
+//   - Seq.Combine
+func (s *SeqOf[T]) Combine(opt ...StepOpt) *SeqOf[T] {
+	s.seq.step(CombineStep, append(slices.Clip(opt), checked[T](Combine()))...)
+	return s
+}
+
+// Filter adds a typed Filter step to the Seq.
+// See:
+//   - Seq.Filter
+func (s *SeqOf[T]) Filter(ff func(in T) bool, opt ...StepOpt) *SeqOf[T] {
+	s.seq.step(FilterStep, append(slices.Clip(opt), checked[T](Filter(func(in any) bool {
+		v, _ := glow.Cast[T](in)
+		return ff(v)
+	})))...)
+	return s
+}
+
+// Capture adds a typed Capture step to the Seq.
+// See:
+//   - Seq.Capture
+func (s *SeqOf[T]) Capture(cf func(ctx context.Context, in T) error, opt ...StepOpt) *SeqOf[T] {
+	s.seq.Capture(func(ctx context.Context, in any) error {
+		v, err := glow.Cast[T](in)
+		if err != nil {
+			return err
+		}
+		return cf(ctx, v)
+	}, opt...)
+	return s
+}
+
+// Collect adds a typed Collect step to the Seq.
+// See:
+//   - Seq.Collect
+func (s *SeqOf[T]) Collect(cb func([]T), compare func(a T, b T) int, opt ...StepOpt) *SeqOf[T] {
+	var anyCompare func(a any, b any) int
+	if compare != nil {
+		anyCompare = func(a any, b any) int {
+			x, _ := glow.Cast[T](a)
+			y, _ := glow.Cast[T](b)
+			return compare(x, y)
+		}
+	}
+	s.seq.step(CollectStep, append(slices.Clip(opt), checked[T](Collect(func(items []any) {
+		out := make([]T, 0, len(items))
+		for _, item := range items {
+			v, _ := glow.Cast[T](item)
+			out = append(out, v)
+		}
+		cb(out)
+	}, anyCompare)))...)
+	return s
+}
+
+// Count adds a Count step to the Seq.
+// See:
+//   - Seq.Count
+func (s *SeqOf[T]) Count(cb func(num int), opt ...StepOpt) *SeqOf[T] {
+	s.seq.step(CountStep, append(slices.Clip(opt), checked[T](Count(cb)))...)
+	return s
+}
+
+func (s *SeqOf[T]) Run(ctx context.Context) *SeqOf[T] {
+	s.seq.Run(ctx)
+	return s
+}
+
+func (s *SeqOf[T]) Stop() *SeqOf[T] {
+	s.seq.Stop()
+	return s
+}
+
+func (s *SeqOf[T]) Draw(name string) *SeqOf[T] {
+	s.seq.Draw(name)
+	return s
+}
+
+func (s *SeqOf[T]) Uptime(uf func(d time.Duration)) *SeqOf[T] {
+	s.seq.Uptime(uf)
+	return s
+}
+
+func (s *SeqOf[T]) Error() error {
+	return s.seq.Error()
+}
+
+// Seq returns the underlying untyped Seq.
+func (s *SeqOf[T]) Seq() *Seq {
+	return s.seq
+}
+
+// checked makes the Step fail for data that is not of type T, ahead of the Step function.
+func checked[T any](opt StepOpt) StepOpt {
+	return func(o *stepOpts) {
+		opt(o)
+		sf := o.sf
+		o.sf = func(ctx context.Context, in any, emit func(any)) error {
+			if _, err := glow.Cast[T](in); err != nil {
+				return err
+			}
+			return sf(ctx, in, emit)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package flow
 
 import (
 	"context"
+	"github.com/lnashier/glow"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type StepKind int
@@ -26,6 +28,14 @@ func (k StepKind) String() string {
 		return "peek"
 	case CombineStep:
 		return "combine"
+	case WindowStep:
+		return "window"
+	case GroupStep:
+		return "group"
+	case ReduceStep:
+		return "reduce"
+	case JoinStep:
+		return "join"
 	default:
 		return "unknown"
 	}
@@ -40,11 +50,16 @@ const (
 	CountStep
 	PeekStep
 	CombineStep
+	WindowStep
+	GroupStep
+	ReduceStep
+	JoinStep
 )
 
 var linearKinds = []StepKind{
 	PeekStep,
 	CombineStep,
+	WindowStep,
 }
 
 type Step struct {
@@ -58,10 +73,21 @@ type stepOpts struct {
 	kind        StepKind
 	key         string
 	sf          func(context.Context, any, func(any)) error
+	flush       func(ctx context.Context, replica int, emit func(any)) error // emits held data at the end of the stream
+	partition   func(any) string                                             // key extractor partitioning data among replicas
 	replicas    int
 	distributor bool
+	router      func(any) []string
+	partitioner func(any) string // key extractor partitioning data among next Steps
+	ack         func(any, error)
+	nodeOpts    []glow.NodeOpt // additional options for the nodes of the Step
 	connections []string
+	deadLetters []string // upstream steps sending failed data
 	callback    func()
+	watermark   time.Duration // how far the watermark trails the latest event time, for window steps
+	lateness    time.Duration // how long windows are kept after the watermark passes them, for window steps
+	join        *joinOpts     // joined steps and bounds, for join steps
+	err         error         // invalid options, reported when the Plan is built
 }
 
 func (o *stepOpts) apply(opt ...StepOpt) *stepOpts {
@@ -87,6 +113,58 @@ func Distributor() StepOpt {
 	}
 }
 
+// Router enables a Step to route each data point to the next Step(s) identified by the keys
+// returned from the routing function. Routed data is broadcast to all replicas of the next Step.
+// Data is dropped when returned keys don't match any next Step.
+func Router(rf func(in any) []string) StepOpt {
+	return func(o *stepOpts) {
+		o.router = rf
+	}
+}
+
+// Partition enables a Step to partition data among next Step, and it's replicas, by the key taken from the data
+// with the key extractor. Data with the same key always goes to the same replica, which keeps the order of data
+// per key and lets stateful replicas own a range of keys.
+// GroupByKey and ReduceByKey partition data among their replicas on their own.
+// See
+//   - Replicas
+//   - glow.Partition
+func Partition(kf func(in any) string) StepOpt {
+	return func(o *stepOpts) {
+		o.partitioner = kf
+	}
+}
+
+// Ack enables acknowledgements for data read by a Read step.
+// The function is called once for every data point when the data point and
+// all the data derived from it are consumed by subsequent steps.
+// See:
+//   - glow.AckFunc
+func Ack(af func(in any, err error)) StepOpt {
+	return func(o *stepOpts) {
+		o.ack = af
+	}
+}
+
+// RateLimit limits the Step to process r data points per second, allowing bursts of up to burst data points.
+// For a Read step, emitted data is paced. The limit is shared by all replicas of the Step.
+// See:
+//   - glow.RateLimit
+func RateLimit(r float64, burst int) StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.RateLimit(r, burst))
+	}
+}
+
+// Retry retries failed calls of the Step function according to the policy.
+// See:
+//   - glow.Retry
+func Retry(p glow.RetryPolicy) StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.Retry(p))
+	}
+}
+
 // Replicas sets the number of replicas for the Step, determining how many instances
 // of the Step will run concurrently. Depending on whether the preceding Step is in distributing
 // or broadcasting mode, these replicas will either operate in a synchronized manner,
@@ -99,6 +177,25 @@ func Replicas(v int) StepOpt {
 	}
 }
 
+// Workers runs the Step function on up to k goroutines at once, within a single Step.
+// Unlike Replicas, the Step remains one Node in the Network.
+// See:
+//   - glow.Workers
+func Workers(k int) StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.Workers(k))
+	}
+}
+
+// Ordered makes the Step with workers emit data in the order it came in.
+// See:
+//   - glow.Ordered
+func Ordered() StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.Ordered())
+	}
+}
+
 // Connection sets up a connection between a Step and the Steps identified by the provided key(s).
 // The provided keys represent upstream steps, enabling data to flow from these Steps to the current Step.
 // Upstream steps can either distribute or broadcast data.
@@ -108,6 +205,35 @@ func Connection(key ...string) StepOpt {
 	}
 }
 
+// OnError sets what the Step does when the Step function fails for incoming data.
+// See:
+//   - glow.OnError
+func OnError(p glow.ErrorPolicy) StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.OnError(p))
+	}
+}
+
+// OnPanic sets what the Step does when the Step function panics for incoming data.
+// See:
+//   - glow.OnPanic
+func OnPanic(p glow.ErrorPolicy) StepOpt {
+	return func(o *stepOpts) {
+		o.nodeOpts = append(o.nodeOpts, glow.OnPanic(p))
+	}
+}
+
+// DeadLetterConnection sets up a dead-letter connection between a Step and the Steps identified by the provided key(s).
+// Upstream steps with glow.DeadLetterOnError error policy send data they failed for, as glow.FailedData,
+// to the current Step.
+// See:
+//   - OnError
+func DeadLetterConnection(key ...string) StepOpt {
+	return func(o *stepOpts) {
+		o.deadLetters = append(o.deadLetters, key...)
+	}
+}
+
 // Read retrieves data from a specified source using a provided reader function.
 // The reader function is called with a context and an emit function, responsible for
 // reading data and emitting it. The emitted data can be of any type.
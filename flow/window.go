@@ -0,0 +1,246 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Window is the result of a window step, carrying the data that fell in the window.
+type Window struct {
+	Start time.Time // inclusive
+	End   time.Time // exclusive
+	Data  []any     // in the order of arrival
+	Late  bool      // whether the window was emitted before, and it is emitted again with late data
+}
+
+// TumblingWindow groups data into fixed-size, non-overlapping windows by the event time taken from the data
+// with the timestamp extractor. A window is emitted downstream as Window once the watermark passes its end.
+// Windows still open at the end of the stream are emitted then.
+// See:
+//   - Watermark
+//   - AllowedLateness
+func TumblingWindow(size time.Duration, ts func(in any) time.Time) StepOpt {
+	return SlidingWindow(size, size, ts)
+}
+
+// SlidingWindow groups data into fixed-size windows starting every slide by the event time taken from the data
+// with the timestamp extractor. Windows overlap when slide is less than size, and data falls in every window
+// covering its event time. A window is emitted downstream as Window once the watermark passes its end.
+// Windows still open at the end of the stream are emitted then. Size and slide must be positive.
+// See:
+//   - Watermark
+//   - AllowedLateness
+func SlidingWindow(size, slide time.Duration, ts func(in any) time.Time) StepOpt {
+	var err error
+	if size <= 0 || slide <= 0 {
+		err = fmt.Errorf("window size %s and slide %s must be positive", size, slide)
+	}
+	return window(ts, err, func(t time.Time) []*pane {
+		var panes []*pane
+		for start := align(t, slide); t.Sub(start) < size; start = start.Add(-slide) {
+			panes = append(panes, &pane{start: start, end: start.Add(size)})
+		}
+		return panes
+	}, false)
+}
+
+// SessionWindow groups data into windows of activity by the event time taken from the data with the timestamp
+// extractor. A window closes after gap of inactivity, and it is emitted downstream as Window once the
+// watermark passes its end. Windows still open at the end of the stream are emitted then. Gap must be positive.
+// See:
+//   - Watermark
+//   - AllowedLateness
+func SessionWindow(gap time.Duration, ts func(in any) time.Time) StepOpt {
+	var err error
+	if gap <= 0 {
+		err = fmt.Errorf("window gap %s must be positive", gap)
+	}
+	return window(ts, err, func(t time.Time) []*pane {
+		return []*pane{{start: t, end: t.Add(gap)}}
+	}, true)
+}
+
+// Watermark sets how far the watermark of a window step trails the latest event time seen,
+// allowing data to arrive out of order by up to the delay. It is zero by default, and it must not be negative.
+func Watermark(delay time.Duration) StepOpt {
+	return func(o *stepOpts) {
+		o.watermark = delay
+	}
+}
+
+// AllowedLateness keeps windows of a window step for the duration after the watermark passes their end.
+// Late data falling in such a window emits the window again, marked late. Data later than that is dropped.
+// It is zero by default, and it must not be negative.
+func AllowedLateness(d time.Duration) StepOpt {
+	return func(o *stepOpts) {
+		o.lateness = d
+	}
+}
+
+// pane holds the data of a window.
+type pane struct {
+	start time.Time
+	end   time.Time
+	data  []arrival // in the order of arrival
+	fired bool      // emitted at least once
+	dirty bool      // got data since last emitted
+}
+
+// arrival is data along with the sequence number it arrived in at the window step.
+type arrival struct {
+	seq  uint64
+	data any
+}
+
+func (p *pane) window() Window {
+	data := make([]any, len(p.data))
+	for i, a := range p.data {
+		data[i] = a.data
+	}
+	return Window{
+		Start: p.start,
+		End:   p.end,
+		Data:  data,
+		Late:  p.fired,
+	}
+}
+
+// window makes a window step with the windows assigned to data by the assign function.
+// Session windows are merged when they overlap. Invalid windows are reported by err when the Plan is built.
+func window(ts func(any) time.Time, err error, assign func(time.Time) []*pane, merge bool) StepOpt {
+	return func(o *stepOpts) {
+		o.kind = WindowStep
+		o.err = err
+
+		mu := &sync.Mutex{}
+		var panes []*pane
+		var latest, watermark time.Time
+		var seq uint64
+
+		// fire takes the windows to emit, and lets go of the windows past allowed lateness.
+		// Must be called with mu held.
+		fire := func(all bool) []Window {
+			var windows []Window
+			kept := panes[:0]
+			for _, p := range panes {
+				if p.dirty && (all || !p.end.After(watermark)) {
+					windows = append(windows, p.window())
+					p.fired = true
+					p.dirty = false
+				}
+				if all || p.end.Add(o.lateness).After(watermark) {
+					kept = append(kept, p)
+				}
+			}
+			clear(panes[len(kept):])
+			panes = kept
+			if all {
+				panes = nil
+			}
+			return windows
+		}
+
+		o.sf = func(ctx context.Context, in any, emit func(any)) error {
+			t := ts(in)
+
+			mu.Lock()
+			seq++
+			for _, p := range assign(t) {
+				if !p.end.Add(o.lateness).After(watermark) {
+					// too late for the window
+					continue
+				}
+				if merge {
+					p = mergePanes(&panes, p)
+				} else if i := slices.IndexFunc(panes, func(q *pane) bool {
+					return q.start.Equal(p.start) && q.end.Equal(p.end)
+				}); i >= 0 {
+					p = panes[i]
+				} else {
+					panes = append(panes, p)
+				}
+				p.data = append(p.data, arrival{seq: seq, data: in})
+				p.dirty = true
+			}
+			if t.After(latest) {
+				latest = t
+				if wm := latest.Add(-o.watermark); wm.After(watermark) {
+					watermark = wm
+				}
+			}
+			slices.SortFunc(panes, comparePanes)
+			windows := fire(false)
+			mu.Unlock()
+
+			for _, w := range windows {
+				emit(w)
+			}
+			return nil
+		}
+
+		o.flush = func(ctx context.Context, _ int, emit func(any)) error {
+			mu.Lock()
+			windows := fire(true)
+			mu.Unlock()
+
+			for _, w := range windows {
+				emit(w)
+			}
+			return nil
+		}
+	}
+}
+
+// mergePanes merges the session pane with the panes it overlaps, and returns the merged pane.
+func mergePanes(panes *[]*pane, p *pane) *pane {
+	kept := (*panes)[:0]
+	for _, q := range *panes {
+		if !q.start.Before(p.end) || !p.start.Before(q.end) {
+			kept = append(kept, q)
+			continue
+		}
+		if q.start.Before(p.start) {
+			p.start = q.start
+		}
+		if q.end.After(p.end) {
+			p.end = q.end
+		}
+		p.data = mergeArrivals(q.data, p.data)
+		p.fired = p.fired || q.fired
+	}
+	*panes = append(kept, p)
+	return p
+}
+
+// mergeArrivals merges data of two panes, both in the order of arrival, keeping the order of arrival.
+func mergeArrivals(a, b []arrival) []arrival {
+	merged := make([]arrival, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].seq <= b[0].seq {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+func comparePanes(a, b *pane) int {
+	if c := a.end.Compare(b.end); c != 0 {
+		return c
+	}
+	return a.start.Compare(b.start)
+}
+
+// align returns the start of the window of the given size holding t, windows are aligned to the Unix epoch.
+func align(t time.Time, size time.Duration) time.Time {
+	d := t.UnixNano() % int64(size)
+	if d < 0 {
+		d += int64(size)
+	}
+	return t.Add(-time.Duration(d))
+}
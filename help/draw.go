@@ -13,3 +13,14 @@ func Draw(net *glow.Network, name string) error {
 	}
 	return os.WriteFile(name, data, os.FileMode(0755))
 }
+
+// Load builds a glow.Network from the DOT description saved at the specified path.
+// See:
+//   - glow.ParseDOT
+func Load(name string, registry map[string]glow.NodeOpt, opt ...glow.NetworkOpt) (*glow.Network, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return glow.ParseDOT(data, registry, opt...)
+}
@@ -0,0 +1,149 @@
+package help
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/lnashier/glow"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Metrics returns http.Handler exporting the glow.Network metrics in the Prometheus text format.
+// Node metrics are labeled by node key, and Link metrics are labeled by from-node and to-node keys.
+// See:
+//   - glow.Network.Stats
+func Metrics(net *glow.Network) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		writeMetrics(bw, net.Stats())
+		bw.Flush()
+	})
+}
+
+func writeMetrics(w *bufio.Writer, s glow.Stats) {
+	running := 0
+	if s.Running {
+		running = 1
+	}
+	header(w, "glow_network_running", "gauge", "Whether the network has nodes running.")
+	sample(w, "glow_network_running", nil, float64(running))
+	header(w, "glow_network_uptime_seconds", "gauge", "Uptime of the current or last session.")
+	sample(w, "glow_network_uptime_seconds", nil, s.Uptime.Seconds())
+	header(w, "glow_network_checkpoint", "gauge", "Id of the last saved checkpoint, zero for none.")
+	sample(w, "glow_network_checkpoint", nil, float64(s.Checkpoint))
+
+	header(w, "glow_node_uptime_seconds", "gauge", "Uptime of the node in the current or last session.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_uptime_seconds", nodeLabels(ns), ns.Uptime.Seconds())
+	}
+	header(w, "glow_node_received_total", "counter", "Data points received by the node.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_received_total", nodeLabels(ns), float64(ns.In))
+	}
+	header(w, "glow_node_emitted_total", "counter", "Data points emitted by the node over its egress links.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_emitted_total", nodeLabels(ns), float64(ns.Out))
+	}
+	header(w, "glow_node_errors_total", "counter", "Errors returned by the node function.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_errors_total", nodeLabels(ns), float64(ns.Errors))
+	}
+	header(w, "glow_node_retries_total", "counter", "Node function calls retried.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_retries_total", nodeLabels(ns), float64(ns.Retries))
+	}
+	header(w, "glow_node_rate", "gauge", "Observed node function calls per second in the current or last session.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_rate", nodeLabels(ns), ns.Rate)
+	}
+	header(w, "glow_node_rate_limit", "gauge", "Node function calls allowed per second, zero for no limit.")
+	for _, ns := range s.Nodes {
+		sample(w, "glow_node_rate_limit", nodeLabels(ns), ns.Limit)
+	}
+	header(w, "glow_node_latency_seconds", "histogram", "Time the node function takes to process incoming data.")
+	for _, ns := range s.Nodes {
+		var cumulative int64
+		for i, bound := range ns.Latency.Bounds {
+			cumulative += ns.Latency.Counts[i]
+			sample(w, "glow_node_latency_seconds_bucket", append(nodeLabels(ns), "le", formatFloat(bound.Seconds())), float64(cumulative))
+		}
+		sample(w, "glow_node_latency_seconds_bucket", append(nodeLabels(ns), "le", "+Inf"), float64(ns.Latency.Count))
+		sample(w, "glow_node_latency_seconds_sum", nodeLabels(ns), ns.Latency.Sum.Seconds())
+		sample(w, "glow_node_latency_seconds_count", nodeLabels(ns), float64(ns.Latency.Count))
+	}
+
+	header(w, "glow_link_transmitted_total", "counter", "Data points transmitted over the link.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_transmitted_total", linkLabels(ls), float64(ls.Tally))
+	}
+	header(w, "glow_link_dropped_total", "counter", "Data points dropped as the link was full.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_dropped_total", linkLabels(ls), float64(ls.Dropped))
+	}
+	header(w, "glow_link_queue_depth", "gauge", "Data points waiting in the link.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_queue_depth", linkLabels(ls), float64(ls.Depth))
+	}
+	header(w, "glow_link_size", "gauge", "Bandwidth of the link.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_size", linkLabels(ls), float64(ls.Size))
+	}
+	header(w, "glow_link_blocked_seconds_total", "counter", "Time the from-node spent waiting to send data over the link.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_blocked_seconds_total", linkLabels(ls), ls.Blocked.Seconds())
+	}
+	header(w, "glow_link_rate", "gauge", "Observed data points per second over the link in the current or last session.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_rate", linkLabels(ls), ls.Rate)
+	}
+	header(w, "glow_link_rate_limit", "gauge", "Data points allowed per second over the link, zero for no limit.")
+	for _, ls := range s.Links {
+		sample(w, "glow_link_rate_limit", linkLabels(ls), ls.Limit)
+	}
+	header(w, "glow_link_paused", "gauge", "Whether the link is paused.")
+	for _, ls := range s.Links {
+		paused := 0
+		if ls.Paused {
+			paused = 1
+		}
+		sample(w, "glow_link_paused", linkLabels(ls), float64(paused))
+	}
+}
+
+func nodeLabels(ns glow.NodeStats) []string {
+	return []string{"node", ns.Key}
+}
+
+func linkLabels(ls glow.LinkStats) []string {
+	return []string{"from", ls.From, "to", ls.To}
+}
+
+func header(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes a metric sample, labels are given as name and value pairs.
+func sample(w *bufio.Writer, name string, labels []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,114 @@
+package help_test
+
+import (
+	"bufio"
+	"context"
+	"github.com/lnashier/glow"
+	"github.com/lnashier/glow/help"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	net := glow.New()
+	var seeded atomic.Int64
+	sink := `sink "quoted" \ key`
+	if _, err := net.AddNode(glow.Key("seed"), glow.BasicFunc(func(ctx context.Context, _ any) (any, error) {
+		if seeded.Add(1) > 3 {
+			return nil, glow.ErrSeedingDone
+		}
+		return "data", nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.AddNode(glow.Key(sink), glow.BasicFunc(func(ctx context.Context, data any) (any, error) {
+		return data, nil
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.AddLink("seed", sink, glow.Size(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(help.Metrics(net))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q", ct)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every line is a comment or a sample of the text format
+	samplePattern := regexp.MustCompile(`^[a-z_]+(\{[a-z]+="([^"\\]|\\.)*"(,[a-z]+="([^"\\]|\\.)*")*\})? \S+$`)
+	var lines []string
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		lines = append(lines, line)
+		if fields := strings.Fields(line); len(fields) == 4 && fields[1] == "TYPE" {
+			if _, ok := types[fields[2]]; ok {
+				t.Errorf("metric %s typed twice", fields[2])
+			}
+			types[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") {
+			continue
+		}
+		if !samplePattern.MatchString(line) {
+			t.Errorf("malformed line %q", line)
+		}
+	}
+
+	for name, kind := range map[string]string{
+		"glow_network_running":        "gauge",
+		"glow_node_received_total":    "counter",
+		"glow_node_emitted_total":     "counter",
+		"glow_node_latency_seconds":   "histogram",
+		"glow_link_transmitted_total": "counter",
+		"glow_link_queue_depth":       "gauge",
+	} {
+		if types[name] != kind {
+			t.Errorf("metric %s typed %q, want %q", name, types[name], kind)
+		}
+	}
+
+	escaped := `node="sink \"quoted\" \\ key"`
+	for _, want := range []string{
+		`glow_network_running 0`,
+		`glow_node_emitted_total{node="seed"} 3`,
+		`glow_node_received_total{` + escaped + `} 3`,
+		`glow_node_emitted_total{` + escaped + `} 0`,
+		`glow_node_errors_total{` + escaped + `} 0`,
+		`glow_node_latency_seconds_bucket{` + escaped + `,le="+Inf"} 3`,
+		`glow_node_latency_seconds_count{` + escaped + `} 3`,
+		`glow_link_transmitted_total{from="seed",to="sink \"quoted\" \\ key"} 3`,
+		`glow_link_size{from="seed",to="sink \"quoted\" \\ key"} 2`,
+		`glow_link_paused{from="seed",to="sink \"quoted\" \\ key"} 0`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package glow
 
 import (
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,13 +19,23 @@ import (
 type Link struct {
 	x       *Node
 	y       *Node
-	paused  bool
-	removed bool
-	closed  bool
+	paused  atomic.Bool
+	removed atomic.Bool
+	closed  atomic.Bool
 	once    sync.Once
-	ch      chan any
+	ch      chan packet
 	size    int
-	tally   int
+	tally   atomic.Int64 // count of data transmitted
+	recent  atomic.Int64 // count of data transmitted in the session
+	blocked atomic.Int64 // time spent waiting to send data
+	dropped atomic.Int64 // count of data dropped as the Link was full
+	sampled atomic.Int64 // count of data offered while the Link was full, in sample mode
+
+	backpressure backpressure
+	limiter      *limiter
+	deadLetter   bool
+	weight       int         // for Weighted distribution, see Weight
+	durable      *durableLog // nil unless the Link is durable
 }
 
 type LinkOpt func(*Link)
@@ -43,22 +55,24 @@ func Size(k int) LinkOpt {
 
 // Tally returns the total count of data transmitted over the link thus far.
 func (l *Link) Tally() int {
-	return l.tally
+	return int(l.tally.Load())
 }
 
 func (l *Link) Uptime() time.Duration {
-	if l.removed || l.paused {
+	if l.removed.Load() || l.paused.Load() {
 		return 0
 	}
-	if !l.x.session.start.IsZero() && !l.y.session.start.IsZero() {
-		stop := l.x.session.stop
+	xStart, xStop := l.x.session.times()
+	yStart, yStop := l.y.session.times()
+	if !xStart.IsZero() && !yStart.IsZero() {
+		stop := xStop
 		if stop.IsZero() {
-			stop = l.y.session.stop
+			stop = yStop
 		}
 		if stop.IsZero() {
 			stop = time.Now()
 		}
-		return stop.Sub(l.y.session.start)
+		return stop.Sub(yStart)
 	}
 
 	return 0
@@ -66,16 +80,18 @@ func (l *Link) Uptime() time.Duration {
 
 // AddLink connects from-node to to-node.
 // Once Link is made, nodes are said to be communicating over the Link in the direction from -> to.
+// When both nodes are typed (see BasicFuncOf, EmitFuncOf), output type of from-node must match input type of to-node.
+// Link can be added while the Network is running, nodes that are not yet running are brought up.
 // See:
 //   - RemoveLink
 //   - PauseLink
 //   - ResumeLink
 func (n *Network) AddLink(from, to string, opt ...LinkOpt) error {
-	if link, _ := n.link(from, to); link != nil {
-		if link.removed {
-			return ErrNetworkNeedPurging
-		}
-		return ErrLinkAlreadyExists
+	n.mu.RLock()
+	err := n.linkable(from, to)
+	n.mu.RUnlock()
+	if err != nil {
+		return err
 	}
 
 	xNode, err := n.Node(from)
@@ -87,36 +103,42 @@ func (n *Network) AddLink(from, to string, opt ...LinkOpt) error {
 		return err
 	}
 
+	link := &Link{
+		x: xNode,
+		y: yNode,
+	}
+	link.apply(opt...)
+
+	out := xNode.out
+	if link.deadLetter {
+		out = failedDataType
+	}
+	if !typesMatch(out, yNode.in) {
+		return ErrTypeMismatch
+	}
+
 	if n.preventCycles && n.checkCycle(from, to) {
 		return ErrCyclesNotAllowed
 	}
 
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
+	if link.durable != nil {
+		if err = link.durable.open(); err != nil {
+			return err
+		}
+	}
 
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	link := &Link{
-		x: xNode,
-		y: yNode,
-	}
-	link.apply(opt...)
-
-	if xNode.distributor {
-		// if there exists another egress for node-x then get existing channel
-		if xEgress := n.egress[xNode.Key()]; len(xEgress) > 0 {
-			for _, xLink := range xEgress {
-				link.ch = xLink.ch
-				break
-			}
+	// a Link may have been added in the meantime
+	if err = n.linkable(from, to); err != nil {
+		if link.durable != nil {
+			link.durable.close()
 		}
+		return err
 	}
 
-	if link.ch == nil {
-		link.ch = make(chan any, link.size)
-	}
+	link.ch = make(chan packet, link.size)
 
 	if _, ok := n.egress[from]; !ok {
 		n.egress[from] = make(map[string]*Link)
@@ -128,10 +150,18 @@ func (n *Network) AddLink(from, to string, opt ...LinkOpt) error {
 	}
 	n.ingress[to][from] = link
 
+	if err = n.join(link); err != nil {
+		_ = n.removeLink(link)
+		return err
+	}
+
+	n.notify()
+
 	return nil
 }
 
 // RemoveLink disconnects "from" Node and "to" Node.
+// When the Network is running, "from" Node stops sending data over the Link right away.
 // See:
 //   - AddLink
 //   - PauseLink
@@ -142,14 +172,11 @@ func (n *Network) RemoveLink(from, to string) error {
 		return err
 	}
 
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
-
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	link.removed = true
+	link.removed.Store(true)
+	n.notify()
 
 	return nil
 }
@@ -157,10 +184,14 @@ func (n *Network) RemoveLink(from, to string) error {
 func (n *Network) removeLink(l *Link) error {
 	delete(n.ingress[l.y.Key()], l.x.Key())
 	delete(n.egress[l.x.Key()], l.y.Key())
+	if l.durable != nil {
+		l.durable.close()
+	}
 	return nil
 }
 
 // PauseLink pauses communication from Node and to Node.
+// When the Network is running, "from" Node holds data for the Link until it is resumed.
 // See:
 //   - AddLink
 //   - ResumeLink
@@ -171,24 +202,22 @@ func (n *Network) PauseLink(from, to string) error {
 		return err
 	}
 
-	if link.paused {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if link.paused.Load() {
 		// parity with AddLink
 		return ErrLinkAlreadyPaused
 	}
 
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
-
-	n.mu.Lock()
-	defer n.mu.Unlock()
-
-	link.paused = true
+	link.paused.Store(true)
+	n.notify()
 
 	return nil
 }
 
 // ResumeLink resumes communication from node and to node.
+// When the Network is running, nodes that are not yet running are brought up.
 // See:
 //   - PauseLink
 func (n *Network) ResumeLink(from, to string) error {
@@ -197,14 +226,20 @@ func (n *Network) ResumeLink(from, to string) error {
 		return err
 	}
 
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
-
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	link.paused = false
+	if !link.paused.Load() {
+		return nil
+	}
+
+	link.paused.Store(false)
+	if err = n.join(link); err != nil {
+		link.paused.Store(true)
+		return err
+	}
+
+	n.notify()
 
 	return nil
 }
@@ -218,13 +253,24 @@ func (n *Network) Link(from, to string) (*Link, error) {
 	if err != nil {
 		return nil, err
 	}
-	if link.removed {
+	if link.removed.Load() {
 		return nil, ErrLinkNotFound
 	}
 
 	return link, nil
 }
 
+// linkable checks that from-node can be connected to to-node. Must be called with Network.mu held.
+func (n *Network) linkable(from, to string) error {
+	if link, _ := n.link(from, to); link != nil {
+		if link.removed.Load() {
+			return ErrNetworkNeedPurging
+		}
+		return ErrLinkAlreadyExists
+	}
+	return nil
+}
+
 func (n *Network) link(from, to string) (*Link, error) {
 	outLinks, ok := n.egress[from]
 	if !ok {
@@ -288,23 +334,33 @@ func (n *Network) Egress(key string) []*Link {
 	return links
 }
 
+// linkAttr returns the log attribute for the Link.
+func linkAttr(l *Link) slog.Attr {
+	return slog.String("link", l.x.Key()+"->"+l.y.Key())
+}
+
 // closeEgress closes all outgoing Link(s) for the Node.
 func (n *Network) closeEgress(node *Node) {
 	for _, link := range n.Egress(node.Key()) {
 		link.once.Do(func() {
 			close(link.ch)
-			link.closed = true
+			link.closed.Store(true)
 		})
 	}
 }
 
-// refreshEgress opens all outgoing Link(s) for the Node.
+// refreshEgress opens all outgoing Link(s) for the Node. Must be called with Network.mu held.
 func (n *Network) refreshEgress(node *Node) {
-	for _, link := range n.Egress(node.Key()) {
-		if link.closed {
-			link.closed = false
+	for _, link := range n.egress[node.Key()] {
+		link.recent.Store(0)
+		if link.durable != nil {
+			link.durable.rewind()
+		}
+		// data left in durable Link is delivered from its log
+		if link.closed.Load() || link.durable != nil {
+			link.closed.Store(false)
 			link.once = sync.Once{}
-			link.ch = make(chan any, link.size)
+			link.ch = make(chan packet, link.size)
 		}
 	}
 }
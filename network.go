@@ -2,36 +2,59 @@ package glow
 
 import (
 	"context"
-	"fmt"
 	"golang.org/x/sync/errgroup"
+	"log/slog"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Network represents nodes and their links.
 type Network struct {
 	mu                  *sync.RWMutex
+	changed             chan struct{} // closed and renewed whenever links change
 	session             *session
-	log                 func(format string, a ...any)
+	logger              *slog.Logger
+	logPayloads         bool
 	nodes               map[string]*Node            // stores all nodes
 	ingress             map[string]map[string]*Link // stores all ingress links for all nodes.
 	egress              map[string]map[string]*Link // stores all egress links for all nodes.
 	stopGracetime       time.Duration
+	drain               bool
 	ignoreIsolatedNodes bool
 	preventCycles       bool
+	checkpoints         *checkpointer // nil unless checkpoints are enabled
 }
 
 type NetworkOpt func(*Network)
 
-// Verbose enables Network to send logs to stdout.
+// Verbose enables Network to send logs to stdout in text format at debug level.
+// Data going through the Network is not logged unless LogPayloads is set.
 func Verbose() NetworkOpt {
 	return func(n *Network) {
-		n.log = func(format string, a ...any) {
-			fmt.Println(fmt.Sprintf("[%s] %s", time.Now().Format("2006-01-02 15:04:05.000"), fmt.Sprintf(format, a...)))
+		n.logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+}
+
+// Logger sets the logger for the Network.
+// Logs carry the attributes session, node, role and link where applicable.
+// Data going through the Network is not logged unless LogPayloads is set.
+func Logger(l *slog.Logger) NetworkOpt {
+	return func(n *Network) {
+		if l != nil {
+			n.logger = l
 		}
 	}
 }
 
+// LogPayloads enables logging of data going through the Network at debug level.
+func LogPayloads() NetworkOpt {
+	return func(n *Network) {
+		n.logPayloads = true
+	}
+}
+
 // IgnoreIsolatedNodes allows the Network to run even when there are isolated nodes.
 func IgnoreIsolatedNodes() NetworkOpt {
 	return func(n *Network) {
@@ -46,6 +69,20 @@ func StopGracetime(t time.Duration) NetworkOpt {
 	}
 }
 
+// Drain makes Network.Stop drain the Network instead of cancelling it: seed-nodes stop emitting, and data in flight
+// goes through the rest of the Network, which shuts down node by node as their ingress Link(s) close.
+// Network.Start returns once the Network is drained, and the session ends as if the seed-nodes were done seeding,
+// so nodes flush their held data (see FlushFunc).
+// With StopGracetime, the Network is stopped once the grace period passes, whether drained or not.
+// Without it, there is no deadline: the session lasts until the Network is drained, however long that takes.
+// Seed-nodes are expected to return once the context passed to the Node function is done, data they emit until
+// they return is still forwarded.
+func Drain() NetworkOpt {
+	return func(n *Network) {
+		n.drain = true
+	}
+}
+
 // PreventCycles ensures the Network remains a Directed Acyclic Graph (DAG).
 func PreventCycles() NetworkOpt {
 	return func(n *Network) {
@@ -60,7 +97,8 @@ func New(opt ...NetworkOpt) *Network {
 		session: &session{
 			mu: &sync.RWMutex{},
 		},
-		log:     func(format string, a ...any) {},
+		changed: make(chan struct{}),
+		logger:  slog.New(discardHandler{}),
 		nodes:   make(map[string]*Node),
 		ingress: make(map[string]map[string]*Link),
 		egress:  make(map[string]map[string]*Link),
@@ -72,24 +110,54 @@ func New(opt ...NetworkOpt) *Network {
 // Start runs the Network.
 func (n *Network) Start(ctx context.Context) error {
 	n.session.mu.Lock()
-	n.log("Network coming up")
 	defer n.session.mu.Unlock()
-	defer n.log("Network shut down")
 
-	n.session.start = time.Now()
-	n.session.stop = time.Time{} //unset
-	defer func() {
-		n.session.stop = time.Now()
-	}()
-	n.session.ctx, n.session.cancel = context.WithCancel(ctx)
-	if n.stopGracetime > 0 {
-		cancel1 := n.session.cancel
-		n.session.cancel = func() {
-			n.log("Network going down in %s", n.stopGracetime)
+	n.session.id++
+	log := n.logger.With("session", n.session.id)
+	n.session.log.Store(log)
+	log.Info("Network coming up")
+	defer log.Info("Network shut down")
+
+	n.session.span.begin()
+	defer n.session.span.end()
+	sessionCtx, cancel := context.WithCancel(ctx)
+	var draining context.Context
+	switch {
+	case n.drain:
+		var drain func()
+		draining, drain = context.WithCancel(sessionCtx)
+		done := make(chan struct{})
+		defer close(done)
+		cancel1 := cancel
+		cancel = func() {
+			log.Info("Network draining", "gracetime", n.stopGracetime)
+			drain()
+			if n.stopGracetime <= 0 {
+				return
+			}
+			go func() {
+				timer := time.NewTimer(n.stopGracetime)
+				defer timer.Stop()
+				select {
+				case <-done:
+				case <-timer.C:
+					log.Info("Network going down before drained")
+					cancel1()
+				}
+			}()
+		}
+	case n.stopGracetime > 0:
+		cancel1 := cancel
+		cancel = func() {
+			log.Info("Network going down", "gracetime", n.stopGracetime)
 			time.Sleep(n.stopGracetime)
 			cancel1()
 		}
 	}
+	n.mu.Lock()
+	n.session.cancel = cancel
+	n.session.draining = draining
+	n.mu.Unlock()
 
 	n.refreshNodes()
 
@@ -98,26 +166,68 @@ func (n *Network) Start(ctx context.Context) error {
 		return ErrEmptyNetwork
 	}
 
-	n.log("Nodes: %d", len(nodes))
+	log.Debug("Network nodes", "count", len(nodes))
 
-	wg, netCtx := errgroup.WithContext(n.session.ctx)
+	if n.checkpoints != nil {
+		if n.hasCycles() {
+			return ErrCyclesNotAllowed
+		}
+		if err := n.resume(); err != nil {
+			return err
+		}
+	}
 
-	for _, node := range nodes {
-		wg.Go(func() error {
-			return n.nodeUp(netCtx, node)
-		})
+	wg, netCtx := errgroup.WithContext(sessionCtx)
+
+	n.mu.Lock()
+	n.session.ctx = netCtx
+	n.session.wg = wg
+	n.session.runs = make(map[string]*nodeRun)
+	n.session.acks = &sync.Map{}
+	for _, node := range n.nodes {
+		n.launch(node)
+	}
+	n.mu.Unlock()
+
+	defer func() {
+		// whatever is still outstanding didn't make it through the Network
+		dropAcks(n.session.acks)
+
+		n.mu.Lock()
+		n.session.wg = nil
+		n.session.runs = nil
+		n.mu.Unlock()
+	}()
+
+	if n.checkpoints == nil {
+		return wg.Wait()
 	}
 
-	return wg.Wait()
+	checkpointsDone := make(chan struct{})
+	go func() {
+		defer close(checkpointsDone)
+		n.runCheckpoints(netCtx)
+	}()
+
+	err := wg.Wait()
+	<-checkpointsDone
+	if err == nil && sessionCtx.Err() == nil {
+		err = n.finalCheckpoint()
+	}
+	return err
 }
 
 // Stop signals the Network to cease all communications.
 // If stop grace period is set, communications will terminate after that period.
+// If the Network drains (see Drain), communications cease once the Network is drained.
 func (n *Network) Stop() error {
-	n.log("Stopping network")
-	defer n.log("Network signaled to stop")
-	if n.session.cancel != nil {
-		n.session.cancel()
+	n.log().Info("Stopping network")
+	defer n.log().Info("Network signaled to stop")
+	n.mu.RLock()
+	cancel := n.session.cancel
+	n.mu.RUnlock()
+	if cancel != nil {
+		cancel()
 	}
 	return nil
 }
@@ -136,7 +246,7 @@ func (n *Network) Purge() error {
 
 	// clean up removed links
 	for _, link := range links {
-		if link.removed {
+		if link.removed.Load() {
 			err := n.removeLink(link)
 			if err != nil {
 				return err
@@ -158,15 +268,33 @@ func (n *Network) Purge() error {
 }
 
 func (n *Network) Uptime() time.Duration {
-	if n.session.start.IsZero() {
-		return 0
+	return n.session.span.uptime()
+}
+
+// log returns the logger of the current or last session.
+func (n *Network) log() *slog.Logger {
+	if l := n.session.log.Load(); l != nil {
+		return l
 	}
-	if n.session.stop.IsZero() {
-		return time.Since(n.session.start)
+	return n.logger
+}
+
+// payload returns the log attribute for data going through the Network, if payload logging is enabled.
+func (n *Network) payload(data any) slog.Attr {
+	if !n.logPayloads {
+		return slog.Attr{}
 	}
-	return n.session.stop.Sub(n.session.start)
+	return slog.Any("data", data)
 }
 
+// discardHandler drops all logs.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
 func (n *Network) apply(opt ...NetworkOpt) {
 	for _, o := range opt {
 		o(n)
@@ -174,9 +302,294 @@ func (n *Network) apply(opt ...NetworkOpt) {
 }
 
 type session struct {
-	mu     *sync.RWMutex
-	ctx    context.Context
-	cancel func()
-	start  time.Time
-	stop   time.Time
+	mu       *sync.RWMutex
+	id       int64 // sequence number of the session
+	log      atomic.Pointer[slog.Logger]
+	ctx      context.Context
+	cancel   func()
+	draining context.Context // done once the Network is draining, nil unless Drain is set
+	span     span
+	wg       *errgroup.Group
+	acks     *sync.Map           // stores outstanding acks
+	runs     map[string]*nodeRun // stores running state for all launched nodes
+	active   int                 // count of nodes still running
+}
+
+// span captures the start and stop times of a session, it is safe for concurrent use.
+type span struct {
+	mu    sync.RWMutex
+	start time.Time
+	stop  time.Time
+}
+
+func (s *span) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = time.Now()
+	s.stop = time.Time{} //unset
+}
+
+func (s *span) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop = time.Now()
+}
+
+func (s *span) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = time.Time{}
+	s.stop = time.Time{}
+}
+
+func (s *span) times() (start, stop time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.start, s.stop
+}
+
+func (s *span) uptime() time.Duration {
+	start, stop := s.times()
+	if start.IsZero() {
+		return 0
+	}
+	if stop.IsZero() {
+		return time.Since(start)
+	}
+	return stop.Sub(start)
+}
+
+// nodeRun captures the running state of a Node within a session.
+type nodeRun struct {
+	mu      sync.Mutex
+	seed    bool
+	done    bool
+	ctx     context.Context
+	wg      *errgroup.Group
+	read    func(*Link) error
+	links   map[*Link]bool // attached ingress links
+	readers int
+	trigger chan int64      // signals the seed-node to take a checkpoint
+	drain   context.Context // done once the Network is draining, nil unless Drain is set
+	aligned func(id int64)  // takes the checkpoint once its barrier arrived over all ingress links
+	barrier int64           // id of the last checkpoint barrier seen
+	arrived map[*Link]int64 // id of the last checkpoint barrier seen per ingress link
+	expect  int             // count of readers yet to get the barrier being aligned
+	release chan struct{}   // closed once the barrier being aligned is passed, nil if none
+}
+
+// start begins reading all attached ingress Link(s) using the provided read function, until ctx is done.
+func (r *nodeRun) start(ctx context.Context, wg *errgroup.Group, read func(*Link) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+	r.wg = wg
+	r.read = read
+	for link := range r.links {
+		r.spawn(link)
+	}
+}
+
+// attach adds an ingress Link to the run, it reports false if the Node is done reading.
+func (r *nodeRun) attach(link *Link) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.links[link] {
+		return true
+	}
+	if r.done {
+		return false
+	}
+	r.links[link] = true
+	if r.wg != nil {
+		r.spawn(link)
+	}
+	return true
+}
+
+func (r *nodeRun) spawn(link *Link) {
+	r.readers++
+	// a Link attached while a barrier is being aligned is read once the barrier is passed
+	release := r.release
+	if release != nil {
+		r.arrived[link] = r.barrier
+	}
+	r.wg.Go(func() error {
+		defer func() {
+			r.mu.Lock()
+			r.readers--
+			if r.readers == 0 {
+				// no more data is coming in
+				r.done = true
+			}
+			waited := r.release != nil && r.arrived[link] != r.barrier
+			delete(r.arrived, link)
+			if waited {
+				// no barrier is coming over the Link
+				r.expect--
+				if r.expect == 0 {
+					r.pass()
+					return
+				}
+			}
+			r.mu.Unlock()
+		}()
+		if release != nil {
+			select {
+			case <-r.ctx.Done():
+				return nil
+			case <-release:
+			}
+		}
+		return r.read(link)
+	})
+}
+
+// align holds the reader of the ingress Link until the checkpoint barrier arrived over all ingress Link(s)
+// being read, and the last one to get the barrier takes the checkpoint.
+// A newer barrier supersedes the one being aligned, and a stale barrier is passed right away.
+// It returns false if ctx is done while waiting.
+func (r *nodeRun) align(ctx context.Context, link *Link, id int64) bool {
+	r.mu.Lock()
+	switch {
+	case id < r.barrier || (id == r.barrier && r.release == nil):
+		r.mu.Unlock()
+		return true
+	case id > r.barrier:
+		if r.release != nil {
+			close(r.release)
+		}
+		r.barrier = id
+		r.expect = r.readers
+		r.release = make(chan struct{})
+	}
+	r.arrived[link] = id
+	r.expect--
+	if r.expect == 0 {
+		r.pass()
+		return true
+	}
+	release := r.release
+	r.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-release:
+		return true
+	}
+}
+
+// pass takes the checkpoint being aligned, and lets the readers go.
+// Must be called with nodeRun.mu held, which is released.
+func (r *nodeRun) pass() {
+	id := r.barrier
+	r.mu.Unlock()
+	r.aligned(id)
+	r.mu.Lock()
+	close(r.release)
+	r.release = nil
+	r.mu.Unlock()
+}
+
+func (r *nodeRun) isDone() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done
+}
+
+// running reports whether the session has nodes running. Must be called with Network.mu held.
+func (n *Network) running() bool {
+	return n.session.runs != nil && n.session.active > 0
+}
+
+// launch brings the Node up in the session. Must be called with Network.mu held.
+func (n *Network) launch(node *Node) {
+	run := &nodeRun{
+		links:   make(map[*Link]bool),
+		arrived: make(map[*Link]int64),
+		trigger: make(chan int64, 1),
+		drain:   n.session.draining,
+	}
+	for _, link := range n.ingress[node.Key()] {
+		if !link.paused.Load() && !link.removed.Load() {
+			run.links[link] = true
+		}
+	}
+	egress := 0
+	for _, link := range n.egress[node.Key()] {
+		if !link.paused.Load() && !link.removed.Load() {
+			egress++
+		}
+	}
+	run.seed = len(run.links) == 0 && egress > 0
+
+	if len(run.links) == 0 && egress == 0 {
+		if n.ignoreIsolatedNodes {
+			return
+		}
+		n.session.wg.Go(func() error {
+			return ErrIsolatedNodeFound
+		})
+		return
+	}
+
+	n.session.runs[node.Key()] = run
+	n.session.active++
+
+	n.session.wg.Go(func() error {
+		defer func() {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+			run.mu.Lock()
+			run.done = true
+			run.mu.Unlock()
+			n.session.active--
+			if n.checkpoints != nil {
+				n.checkpoints.gone(node.Key())
+			}
+		}()
+		return n.nodeUp(n.session.ctx, node, run)
+	})
+}
+
+// join brings the Link into the running session, if any. Must be called with Network.mu held.
+func (n *Network) join(link *Link) error {
+	if !n.running() {
+		return nil
+	}
+
+	yRun, yOk := n.session.runs[link.y.Key()]
+	if yOk {
+		if yRun.seed {
+			return ErrNodeIsSeeding
+		}
+		if !yRun.attach(link) {
+			return ErrNodeNotRunning
+		}
+	}
+
+	if xRun, ok := n.session.runs[link.x.Key()]; ok {
+		if xRun.isDone() {
+			// from-node has gone away, nothing is coming over the Link
+			link.once.Do(func() {
+				close(link.ch)
+				link.closed.Store(true)
+			})
+		}
+	} else {
+		n.launch(link.x)
+	}
+
+	if !yOk {
+		n.launch(link.y)
+	}
+
+	return nil
+}
+
+// notify wakes up running nodes to pick up changes to links. Must be called with Network.mu held.
+func (n *Network) notify() {
+	close(n.changed)
+	n.changed = make(chan struct{})
 }
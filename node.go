@@ -5,8 +5,10 @@ import (
 	"errors"
 	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
+	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,10 +21,13 @@ import (
 //   - With both egress and ingress Links, Node is considered a transit-node.
 //
 // Node operating modes:
-//   - By default, a Node operates in broadcaster mode unless the distributor flag is set.
+//   - By default, a Node operates in broadcaster mode unless the distributor flag, the router or the partition
+//     key function is set.
 //     In broadcaster mode, Node broadcasts all incoming data to all outgoing links.
 //     When the distributor flag is enabled, a Node distributes incoming data among its outgoing links.
-//     Distributor mode is not functional for isolated and terminal nodes.
+//     When the router is set, a Node routes each data point to the outgoing links picked by the router.
+//     When the partition key function is set, a Node sends data with the same key over the same outgoing link.
+//     Distributor, router and partitioned modes are not functional for isolated and terminal nodes.
 //   - By default, a Node operates in "push-pull" mode: the Network pushes data to BasicFunc,
 //     and it waits for BasicFunc to return with output data, which is then forwarded to connected Node(s).
 //     This behavior can be changed to "push-push" by setting the EmitFunc for the Node.
@@ -32,13 +37,24 @@ type Node struct {
 	key         string
 	f           func(context.Context, any) (any, error)
 	ef          func(context.Context, any, func(any)) error
+	flush       func(context.Context, func(any)) error
 	distributor bool
-	session     nodeSession
-}
-
-type nodeSession struct {
-	start time.Time
-	stop  time.Time
+	strategy    *strategy // nil unless the distributor picks Link(s) by a strategy
+	router      func(any) []string
+	partition   func(any) string
+	ack         func(any, error)
+	limiter     *limiter
+	retry       *RetryPolicy
+	onError     ErrorPolicy
+	onPanic     ErrorPolicy
+	workers     int
+	ordered     bool
+	snapshot    func() ([]byte, error)
+	restore     func([]byte) error
+	in          reflect.Type // input type, set by typed node functions
+	out         reflect.Type // output type, set by typed node functions
+	session     span
+	metrics     nodeMetrics
 }
 
 type NodeOpt func(*Node)
@@ -48,13 +64,14 @@ func (n *Node) Key() string {
 }
 
 func (n *Node) Uptime() time.Duration {
-	if n.session.start.IsZero() {
-		return 0
-	}
-	if n.session.stop.IsZero() {
-		return time.Since(n.session.start)
-	}
-	return n.session.stop.Sub(n.session.start)
+	return n.session.uptime()
+}
+
+// Latency returns the average time the Node function takes to process incoming data thus far.
+// Time spent emitting data, which waits for downstream nodes to take it, is included.
+// Latency of a seed-node is zero as it has no incoming data.
+func (n *Node) Latency() time.Duration {
+	return n.metrics.latency.mean()
 }
 
 func (n *Node) apply(opt ...NodeOpt) {
@@ -78,12 +95,44 @@ func Key(k string) NodeOpt {
 }
 
 // Distributor enables a Node to distribute incoming data among its outgoing links.
+// Data goes over whichever Link takes it first, use RoundRobin, Weighted, LeastQueued or Random to pick
+// the Link by a strategy instead.
 func Distributor() NodeOpt {
 	return func(n *Node) {
 		n.distributor = true
 	}
 }
 
+// Router enables a Node to route each outgoing data point to the Node(s) identified by the keys
+// returned from the routing function. Data is dropped when returned keys don't match any outgoing link.
+func Router(f func(data any) []string) NodeOpt {
+	return func(n *Node) {
+		n.router = f
+	}
+}
+
+// Workers runs the Node function of the Node on up to k goroutines at once, consuming data from all ingress Link(s).
+// Without workers, the Node function is called for one data point at a time per ingress Link.
+// The Node remains one logical Node, e.g. in Network.Stats and the DOT description.
+// Workers are not functional for seed-nodes.
+// See:
+//   - Ordered
+func Workers(k int) NodeOpt {
+	return func(n *Node) {
+		n.workers = k
+	}
+}
+
+// Ordered makes the Node with workers forward output in the order of incoming data over each ingress Link.
+// Output derived from a data point is held until output derived from data that came in earlier is forwarded.
+// See:
+//   - Workers
+func Ordered() NodeOpt {
+	return func(n *Node) {
+		n.ordered = true
+	}
+}
+
 // BasicFunc is responsible for processing incoming data on the Node.
 // Output from the Node is forwarded to downstream connected Node(s).
 func BasicFunc(f func(ctx context.Context, data any) (any, error)) NodeOpt {
@@ -101,13 +150,20 @@ func EmitFunc(f func(ctx context.Context, data any, emit func(any)) error) NodeO
 	}
 }
 
+// FlushFunc is called once the Node has no more incoming data, before its egress Link(s) are closed.
+// It provides a callback where held output data, e.g. of a pending aggregate, can be emitted
+// and forwarded to downstream connected Node(s).
+// FlushFunc is not called when the Network is stopped or the Node failed, and it is not functional for seed-nodes.
+func FlushFunc(f func(ctx context.Context, emit func(any)) error) NodeOpt {
+	return func(n *Node) {
+		n.flush = f
+	}
+}
+
 // AddNode adds a new Node in the Network.
 // Node key is retrieved from the provided [KeyFunc] function if not given.
+// Node can be added while the Network is running, it comes up once linked to other Node(s).
 func (n *Network) AddNode(opt ...NodeOpt) (string, error) {
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
-
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
@@ -128,6 +184,15 @@ func (n *Network) AddNode(opt ...NodeOpt) (string, error) {
 	if node.f != nil && node.ef != nil {
 		return node.Key(), ErrTooManyNodeFunction
 	}
+	modes := 0
+	for _, mode := range []bool{node.distributor, node.router != nil, node.partition != nil} {
+		if mode {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return node.Key(), ErrTooManyNodeModes
+	}
 
 	n.nodes[node.Key()] = node
 
@@ -150,10 +215,6 @@ func (n *Network) Node(k string) (*Node, error) {
 // RemoveNode removes a node with provided key.
 // A node can't be removed if it is linked to any other node in the Network.
 func (n *Network) RemoveNode(k string) error {
-	// check if session is in progress
-	n.session.mu.RLock()
-	defer n.session.mu.RUnlock()
-
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
@@ -190,8 +251,8 @@ func (n *Network) Keys() []string {
 	defer n.mu.RUnlock()
 
 	keys := make([]string, 0, len(n.nodes))
-	for _, node := range n.Nodes() {
-		keys = append(keys, node.Key())
+	for k := range n.nodes {
+		keys = append(keys, k)
 	}
 
 	return keys
@@ -206,7 +267,7 @@ func (n *Network) Seeds() []string {
 	var keys []string
 
 	for k := range n.nodes {
-		if len(n.Ingress(k)) == 0 && len(n.Egress(k)) > 0 {
+		if len(n.ingress[k]) == 0 && len(n.egress[k]) > 0 {
 			keys = append(keys, k)
 		}
 	}
@@ -223,7 +284,7 @@ func (n *Network) Terminals() []string {
 	var keys []string
 
 	for k := range n.nodes {
-		if len(n.Ingress(k)) > 0 && len(n.Egress(k)) == 0 {
+		if len(n.ingress[k]) > 0 && len(n.egress[k]) == 0 {
 			keys = append(keys, k)
 		}
 	}
@@ -231,42 +292,17 @@ func (n *Network) Terminals() []string {
 	return keys
 }
 
-func (n *Network) nodeUp(ctx context.Context, node *Node) error {
-	n.log("Node(%s) coming up", node.Key())
-	defer n.log("Node(%s) shut down", node.Key())
-
-	ingress := slices.DeleteFunc(n.Ingress(node.Key()), func(l *Link) bool {
-		return l.paused || l.removed
-	})
-	egress := slices.DeleteFunc(n.Egress(node.Key()), func(l *Link) bool {
-		return l.paused || l.removed
-	})
+func (n *Network) nodeUp(ctx context.Context, node *Node, run *nodeRun) error {
+	log := n.log().With("node", node.Key(), "role", n.role(node, run))
+	log.Info("Node coming up")
+	defer log.Info("Node shut down")
 
-	n.log("Node(%s) ingress(%v) egress(%v)", node.Key(), ingress, egress)
+	node.session.begin()
+	defer node.session.end()
 
-	if len(ingress) == 0 && len(egress) == 0 {
-		if n.ignoreIsolatedNodes {
-			return nil
-		}
-		return ErrIsolatedNodeFound
-	}
-
-	node.session.start = time.Now()
-	node.session.stop = time.Time{}
-	defer func() {
-		node.session.stop = time.Now()
-	}()
-
-	var egressYs string
-	for _, egressLink := range egress {
-		egressYs += egressLink.y.Key() + ","
-	}
-	egressYs = strings.TrimSuffix(egressYs, ",")
-
-	switch {
-	case len(ingress) == 0 && len(egress) > 0:
-		n.log("Seed Node(%s) is running", node.Key())
-		defer n.log("Seed Node(%s) going away", node.Key())
+	if run.seed {
+		log.Debug("Node is running")
+		defer log.Debug("Node going away")
 		defer n.closeEgress(node)
 
 		// When the seed-node has EmitFunc set, the node function is called once.
@@ -274,8 +310,19 @@ func (n *Network) nodeUp(ctx context.Context, node *Node) error {
 		// this emission phase. After the Node emit function returns, the seed-node
 		// gracefully shuts down, concluding its emission process. This mode allows
 		// the seed-node to continuously emit data points before terminating its execution.
-		nf := node.ef
-		if nf == nil {
+		nf := n.retried(node, log, recovered(node, node.ef))
+		// EmitFunc is called once, so emitted data is paced instead
+		emitLimiter := node.limiter
+		if node.ef == nil {
+			emitLimiter = nil
+			f := n.retried(node, log, recovered(node, func(ctx context.Context, _ any, emit func(any)) error {
+				nodeData, err := node.f(ctx, nil)
+				if err != nil {
+					return err
+				}
+				emit(nodeData)
+				return nil
+			}))
 			// When the seed-node has BasicFunc set, the node function is invoked repeatedly
 			// until it does not return ErrSeedingDone or ErrNodeGoingAway.
 			// This indicates that the seed-node has completed its seeding process.
@@ -283,35 +330,82 @@ func (n *Network) nodeUp(ctx context.Context, node *Node) error {
 				for {
 					select {
 					case <-ctx.Done():
-						n.log("Seed(%s) net-ctx done", node.Key())
+						log.Debug("Network context done")
 						return nil
 					default:
-						nodeData, nodeErr := node.f(ctx, nil)
-						if nodeErr != nil {
+						if node.limiter != nil && !node.limiter.wait(ctx) {
+							log.Debug("Network context done")
+							return nil
+						}
+						node.metrics.paced.Add(1)
+						if nodeErr := f(ctx, nil, emit); nodeErr != nil {
 							if errors.Is(nodeErr, ErrSeedingDone) || errors.Is(nodeErr, ErrNodeGoingAway) {
-								n.log("Seed(%s) %v", node.Key(), nodeErr)
+								log.Debug("Seeding done", "reason", nodeErr)
 								return nil
 							}
-							n.log("Seed(%s) Err: %v", node.Key(), nodeErr)
+							log.Error("Node function failed", "error", nodeErr)
+							node.metrics.errors.Add(1)
 							return nodeErr
 						}
-						emit(nodeData)
 					}
 				}
 			}
 		}
 
 		nodeWg, nodeCtx := errgroup.WithContext(ctx)
-		nodeDataCh := make(chan any)
+		nodeDataCh := make(chan packet)
+
+		// A draining Network stops the Node function, data it emits until it returns is still forwarded.
+		emitCtx := nodeCtx
+		if run.drain != nil {
+			var stopEmitting context.CancelFunc
+			emitCtx, stopEmitting = context.WithCancel(nodeCtx)
+			defer stopEmitting()
+			defer context.AfterFunc(run.drain, stopEmitting)()
+		}
+
+		// barrier takes the checkpoint the seed-node is signaled for, if any,
+		// and hands the checkpoint barrier over behind the data emitted so far.
+		barrier := func() {
+			select {
+			case id := <-run.trigger:
+				n.takeCheckpoint(node, id)
+				select {
+				case <-nodeCtx.Done():
+				case nodeDataCh <- packet{barrier: id}:
+				}
+			default:
+			}
+		}
 
 		nodeWg.Go(func() error {
 			// There is no incoming data, so nothing is passed to node function.
-			nodeErr := nf(nodeCtx, nil, func(nodeData any) {
+			nodeErr := nf(emitCtx, nil, func(nodeData any) {
+				if emitLimiter != nil && !emitLimiter.wait(nodeCtx) {
+					return
+				}
+				if node.ef != nil {
+					// BasicFunc calls are counted as they are made
+					node.metrics.paced.Add(1)
+				}
+				p := packet{data: nodeData}
+				if node.ack != nil {
+					p.ack = newAck(nodeData, node.ack, n.session.acks)
+				}
 				select {
 				case <-nodeCtx.Done():
-				case nodeDataCh <- nodeData:
+					p.ack.done(ErrDataDropped)
+				case nodeDataCh <- p:
+					barrier()
 				}
 			})
+			if errors.Is(nodeErr, context.Canceled) && emitCtx.Err() != nil && nodeCtx.Err() == nil {
+				log.Debug("Seeding done", "reason", "network draining")
+				nodeErr = nil
+			}
+			if nodeErr == nil && nodeCtx.Err() == nil {
+				barrier()
+			}
 			close(nodeDataCh)
 			return nodeErr
 		})
@@ -320,36 +414,23 @@ func (n *Network) nodeUp(ctx context.Context, node *Node) error {
 			for {
 				select {
 				case <-nodeCtx.Done():
-					n.log("Seed(%s) node-ctx done while reading emitted data", node.Key())
+					log.Debug("Node context done while reading emitted data")
 					return nil
 				case nodeData, ok := <-nodeDataCh:
 					if !ok {
-						n.log("Seed(%s) Data Channel Closed", node.Key())
+						log.Debug("Emitted data closed")
 						return nil
 					}
-					if node.distributor {
-						// Get any egress link, they all share same channel
-						egressLink := egress[0]
-						n.log("Seed(%s/%s) Distributing Data(%v) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, egressYs)
-						select {
-						case <-ctx.Done():
-							n.log("Seed(%s/%s) net-ctx done while distributing Data(%v) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, egressYs)
+					if nodeData.barrier != 0 {
+						if !n.broadcastBarrier(ctx, node, nodeData.barrier) {
+							log.Debug("Network context done while forwarding checkpoint barrier")
 							return nil
-						case egressLink.ch <- nodeData:
-							n.log("Seed(%s/%s) Distributed Data(%v) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, egressYs)
-						}
-					} else {
-						n.log("Seed(%s) Broadcasting Data(%v) To Nodes(%s)", node.Key(), nodeData, egressYs)
-						for _, egressLink := range egress {
-							n.log("Seed(%s/%s) Sending Data(%v) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, egressLink.y.Key())
-							select {
-							case <-ctx.Done():
-								n.log("Seed(%s/%s) net-ctx done while sending Data(%v) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, egressLink.y.Key())
-								return nil
-							case egressLink.ch <- nodeData:
-								n.log("Seed(%s/%s) Sent Data(%v) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, egressLink.y.Key())
-							}
 						}
+						continue
+					}
+					if !n.forward(ctx, node, nodeData) {
+						log.Debug("Network context done while forwarding data", n.payload(nodeData.data))
+						return nil
 					}
 				}
 			}
@@ -357,182 +438,584 @@ func (n *Network) nodeUp(ctx context.Context, node *Node) error {
 
 		if err := nodeWg.Wait(); err != nil {
 			if errors.Is(err, ErrSeedingDone) || errors.Is(err, ErrNodeGoingAway) {
-				n.log("Seed(%s) %v", node.Key(), err)
+				log.Debug("Seeding done", "reason", err)
 				return nil
 			}
-			n.log("Seed(%s) Err: %v", node.Key(), err)
+			log.Error("Node failed", "error", err)
 			return err
 		}
-	case len(ingress) > 0 && len(egress) > 0:
-		n.log("Transit Node(%s) is running", node.Key())
-		defer n.log("Transit Node(%s) going away", node.Key())
-		defer n.closeEgress(node)
 
-		// When transit-node has EmitFunc set, the node function is called for every incoming data point.
-		// Transit-node can choose to emit as many data points and return control back to get next incoming data point.
-		nf := node.ef
-		if nf == nil {
-			// Turn basic function to emit function
-			nf = func(ctx context.Context, in any, emit func(any)) error {
-				out, err := node.f(ctx, in)
-				if err != nil {
-					return err
-				}
-				emit(out)
-				return nil
+		return nil
+	}
+
+	// A transit-node and a terminal-node are run alike. Output of a terminal-node is dropped
+	// as there is nowhere to forward it, until the Node gains egress Link(s) in the running session.
+	log.Debug("Node is running")
+	defer log.Debug("Node going away")
+	defer n.closeEgress(node)
+
+	// When the node has EmitFunc set, the node function is called for every incoming data point.
+	// The node can choose to emit as many data points and return control back to get next incoming data point.
+	nf := node.ef
+	if nf == nil {
+		// Turn basic function to emit function
+		nf = func(ctx context.Context, in any, emit func(any)) error {
+			out, err := node.f(ctx, in)
+			if err != nil {
+				return err
 			}
+			emit(out)
+			return nil
 		}
+	}
+	nf = n.retried(node, log, recovered(node, nf))
 
-		nodeWg, nodeCtx := errgroup.WithContext(ctx)
+	// Node function calls are bounded across all ingress Link(s) when the Node has workers
+	var workers chan struct{}
+	if node.workers > 1 {
+		workers = make(chan struct{}, node.workers)
+	}
+
+	nodeWg, nodeCtx := errgroup.WithContext(ctx)
+
+	// Once the checkpoint barrier arrived over all ingress Link(s), the Node takes the checkpoint
+	// and passes the barrier on.
+	run.aligned = func(id int64) {
+		n.takeCheckpoint(node, id)
+		n.broadcastBarrier(nodeCtx, node, id)
+	}
 
-		for _, ingressLink := range ingress {
-			nodeWg.Go(func() error {
-				inDataWg, inDataCtx := errgroup.WithContext(nodeCtx)
-				nodeDataCh := make(chan any)
+	// Every ingress Link, including the ones attached while the Node is running, is read on its own.
+	run.start(nodeCtx, nodeWg, func(ingressLink *Link) error {
+		log := log.With(linkAttr(ingressLink))
+		inDataWg, inDataCtx := errgroup.WithContext(nodeCtx)
+		nodeDataCh := make(chan packet)
+
+		// emit hands data derived from incoming data over to be forwarded.
+		emit := func(p packet) {
+			select {
+			case <-inDataCtx.Done():
+				p.ack.done(ErrDataDropped)
+			case nodeDataCh <- p:
+			}
+		}
+
+		// call calls the Node function for the incoming data, derived data is handed over to out.
+		// It returns error if the Node must stop.
+		call := func(inData packet, out func(packet)) error {
+			if node.limiter != nil && !node.limiter.wait(inDataCtx) {
+				inData.ack.done(ErrDataDropped)
+				return ErrNodeGoingAway
+			}
+			node.metrics.paced.Add(1)
+
+			called := time.Now()
+			nodeErr := nf(inDataCtx, inData.data, func(nodeData any) {
+				// data derived from incoming data is tracked along with it
+				inData.ack.add()
+				out(packet{data: nodeData, ack: inData.ack})
+			})
+			node.metrics.latency.observe(time.Since(called))
+			// the Node goes on when failed data is skipped or dead-lettered, still it is acked with the error
+			failed := nodeErr
+			if nodeErr != nil && !errors.Is(nodeErr, ErrNodeGoingAway) {
+				node.metrics.errors.Add(1)
+				if n.divert(inDataCtx, node, inData, nodeErr) {
+					nodeErr = nil
+				}
+			}
+			inData.ack.done(failed)
+			if nodeErr == nil && inData.offset > 0 {
+				n.commit(ingressLink, inData.offset)
+			}
+			return nodeErr
+		}
 
+		// data not committed by the durable Link is delivered first
+		in := ingressLink.ch
+		if ingressLink.durable != nil {
+			replayed := make(chan packet)
+			in = replayed
+			inDataWg.Go(func() error {
+				n.replay(inDataCtx, ingressLink, replayed)
+				return nil
+			})
+		}
+
+		inDataWg.Go(func() error {
+			var pending sync.WaitGroup
+			// order carries results of the Node function calls in the order of incoming data
+			var order chan chan []packet
+			if node.ordered && workers != nil {
+				order = make(chan chan []packet, node.workers)
 				inDataWg.Go(func() error {
-					for {
+					defer close(nodeDataCh)
+					for result := range order {
 						select {
 						case <-inDataCtx.Done():
-							n.log("Node(%s/%s) in-node-ctx done for Node(%s)", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
 							return nil
-						case inData, ok := <-ingressLink.ch:
-							if !ok {
-								n.log("Node(%s/%s) To Node(%s) Link Closed", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
-								close(nodeDataCh)
-								return nil
-							}
-							ingressLink.tally++
-							n.log("Node(%s/%s) Received Data(%v) From(%s)", node.Key(), ingressLink.y.Key(), inData, ingressLink.x.Key())
-
-							nodeErr := nf(inDataCtx, inData, func(nodeData any) {
-								select {
-								case <-inDataCtx.Done():
-								case nodeDataCh <- nodeData:
-								}
-							})
-							if nodeErr != nil {
-								close(nodeDataCh)
-								return nodeErr
+						case data := <-result:
+							for _, p := range data {
+								emit(p)
 							}
 						}
 					}
+					return nil
 				})
+			}
+			defer func() {
+				pending.Wait()
+				if order != nil {
+					close(order)
+				} else {
+					close(nodeDataCh)
+				}
+			}()
 
-				inDataWg.Go(func() error {
-					for {
+			for {
+				select {
+				case <-inDataCtx.Done():
+					log.Debug("Node context done")
+					return nil
+				case inData, ok := <-in:
+					if !ok {
+						log.Debug("Link closed")
+						return nil
+					}
+					if inData.barrier != 0 {
+						// data ahead of the barrier is processed and forwarded before the barrier is aligned
+						pending.Wait()
+						marker := packet{barrier: inData.barrier, flushed: make(chan struct{})}
+						if order != nil {
+							result := make(chan []packet, 1)
+							result <- []packet{marker}
+							select {
+							case <-inDataCtx.Done():
+								return nil
+							case order <- result:
+							}
+						} else {
+							emit(marker)
+						}
 						select {
 						case <-inDataCtx.Done():
-							n.log("Node(%s/%s) node-ctx done for Node(%s) while reading emitted data", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
 							return nil
-						case nodeData, ok := <-nodeDataCh:
-							if !ok {
-								n.log("Node(%s/%s) Data Channel Closed For Node(%s)", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
-								return nil
-							}
+						case <-marker.flushed:
+						}
+						if !run.align(inDataCtx, ingressLink, inData.barrier) {
+							return nil
+						}
+						continue
+					}
+					ingressLink.tally.Add(1)
+					ingressLink.recent.Add(1)
+					node.metrics.in.Add(1)
+					log.Debug("Received data", n.payload(inData.data))
+
+					if workers == nil {
+						if err := call(inData, emit); err != nil {
+							return err
+						}
+						continue
+					}
 
-							if node.distributor {
-								// Get any egress link, they all share same channel
-								egressLink := egress[0]
-								n.log("Node(%s/%s) Distributing Data(%v) Of(%s) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.x.Key(), egressYs)
-								select {
-								case <-nodeCtx.Done():
-									n.log("Node(%s/%s) node-ctx done while distributing Data(%v) Of(%s) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.x.Key(), egressYs)
-									return nil
-								case egressLink.ch <- nodeData:
-									n.log("Node(%s/%s) Distributed Data(%v) Of(%s) To Nodes(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.y.Key(), egressYs)
-								}
-							} else {
-								for _, egressLink := range egress {
-									n.log("Node(%s/%s) Sending Data(%v) Of(%s) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.x.Key(), egressLink.y.Key())
-									select {
-									case <-nodeCtx.Done():
-										n.log("Node(%s/%s) node-ctx done while sending Data(%v) Of(%s) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.x.Key(), egressLink.y.Key())
-										return nil
-									case egressLink.ch <- nodeData:
-										n.log("Node(%s/%s) Sent Data(%v) Of(%s) To Node(%s)", node.Key(), egressLink.x.Key(), nodeData, ingressLink.y.Key(), egressLink.y.Key())
-									}
-								}
-							}
+					select {
+					case <-inDataCtx.Done():
+						inData.ack.done(ErrDataDropped)
+						return nil
+					case workers <- struct{}{}:
+					}
+
+					out := emit
+					var result chan []packet
+					var data []packet
+					if order != nil {
+						// derived data is held until data that came in earlier is handed over
+						result = make(chan []packet, 1)
+						select {
+						case <-inDataCtx.Done():
+							<-workers
+							inData.ack.done(ErrDataDropped)
+							return nil
+						case order <- result:
+						}
+						out = func(p packet) {
+							data = append(data, p)
 						}
 					}
-				})
 
-				if err := inDataWg.Wait(); err != nil {
-					if errors.Is(err, ErrNodeGoingAway) {
-						n.log("Node(%s/%s) %v for Node(%s)", node.Key(), ingressLink.y.Key(), err, ingressLink.x.Key())
+					pending.Add(1)
+					inDataWg.Go(func() error {
+						defer pending.Done()
+						defer func() {
+							<-workers
+						}()
+						err := call(inData, out)
+						if result != nil {
+							result <- data
+						}
+						return err
+					})
+				}
+			}
+		})
+
+		inDataWg.Go(func() error {
+			for {
+				select {
+				case <-inDataCtx.Done():
+					log.Debug("Node context done while reading emitted data")
+					return nil
+				case nodeData, ok := <-nodeDataCh:
+					if !ok {
+						log.Debug("Emitted data closed")
+						return nil
+					}
+					if nodeData.flushed != nil {
+						close(nodeData.flushed)
+						continue
+					}
+					if !n.forward(nodeCtx, node, nodeData) {
+						log.Debug("Node context done while forwarding data", n.payload(nodeData.data))
 						return nil
 					}
-					n.log("Node(%s/%s) Err: %v for Node(%s)", node.Key(), ingressLink.y.Key(), err, ingressLink.x.Key())
-					return err
 				}
+			}
+		})
 
+		if err := inDataWg.Wait(); err != nil {
+			if errors.Is(err, ErrNodeGoingAway) {
+				log.Debug("Node going away", "reason", err)
 				return nil
+			}
+			log.Error("Node function failed", "error", err)
+			return err
+		}
+
+		return nil
+	})
+
+	if err := nodeWg.Wait(); err != nil || node.flush == nil || ctx.Err() != nil {
+		return err
+	}
+
+	log.Debug("Flushing node")
+	flush := recovered(node, func(ctx context.Context, _ any, emit func(any)) error {
+		return node.flush(ctx, emit)
+	})
+	if err := flush(ctx, nil, func(nodeData any) {
+		n.forward(ctx, node, packet{data: nodeData})
+	}); err != nil && !errors.Is(err, ErrNodeGoingAway) {
+		log.Error("Node flush failed", "error", err)
+		node.metrics.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// forward sends data to the egress Link(s) of the Node according to its operating mode.
+// Egress Link(s) are looked up on every call to pick up changes made while the Network is running.
+// Data is counted as emitted by the Node once it has somewhere to go, e.g. output of a terminal-node is not.
+// It returns false if ctx is done before data is forwarded.
+func (n *Network) forward(ctx context.Context, node *Node, p packet) (ok bool) {
+	defer func() {
+		// release the count held for forwarding
+		if ok {
+			p.ack.done(nil)
+		} else {
+			p.ack.done(ErrDataDropped)
+		}
+	}()
+
+	n.mu.RLock()
+	var egress []*Link
+	for _, link := range n.egress[node.Key()] {
+		if !link.removed.Load() && !link.deadLetter {
+			egress = append(egress, link)
+		}
+	}
+	n.mu.RUnlock()
+
+	if len(egress) == 0 {
+		n.log().Debug("No route for data", "node", node.Key(), n.payload(p.data))
+		return true
+	}
+
+	if node.distributor {
+		node.metrics.out.Add(1)
+		if node.strategy != nil {
+			return n.distributeByStrategy(ctx, node, p)
+		}
+		return n.distribute(ctx, node, p)
+	}
+
+	if node.partition != nil {
+		node.metrics.out.Add(1)
+		slices.SortFunc(egress, func(a, b *Link) int {
+			return strings.Compare(a.y.Key(), b.y.Key())
+		})
+		return n.send(ctx, egress[KeyPartition(node.partition(p.data), len(egress))], p)
+	}
+
+	if node.router != nil {
+		routed := false
+		for _, key := range node.router(p.data) {
+			i := slices.IndexFunc(egress, func(l *Link) bool {
+				return l.y.Key() == key
 			})
+			if i < 0 {
+				n.log().Debug("No route for data", "node", node.Key(), "to", key, n.payload(p.data))
+				continue
+			}
+			if !routed {
+				routed = true
+				node.metrics.out.Add(1)
+			}
+			if !n.send(ctx, egress[i], p) {
+				return false
+			}
 		}
+		return true
+	}
 
-		if err := nodeWg.Wait(); err != nil {
-			return err
+	node.metrics.out.Add(1)
+	for _, egressLink := range egress {
+		if !n.send(ctx, egressLink, p) {
+			return false
 		}
-	case len(ingress) > 0 && len(egress) == 0:
-		n.log("Terminal Node(%s) is running", node.Key())
-		defer n.log("Terminal Node(%s) going away", node.Key())
-
-		// A terminal-node behaves same in either scenario, the node function is called for every incoming data point.
-		nf := node.ef
-		if nf == nil {
-			nf = func(ctx context.Context, in any, emit func(any)) error {
-				// There is nowhere to send output of node function.
-				// Therefore, output of terminal node is ignored.
-				_, err := node.f(ctx, in)
-				return err
+	}
+	return true
+}
+
+// distribute sends data over any one of the egress Link(s) of the Node that is ready to take it.
+// Rate limited Link(s) are ready once they have room in their limit.
+// It returns false if ctx is done before data is sent.
+func (n *Network) distribute(ctx context.Context, node *Node, p packet) bool {
+	for {
+		n.mu.RLock()
+		changed := n.changed
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(changed)},
+			{Dir: reflect.SelectRecv}, // set to wait for rate limited Link(s)
+		}
+		var links []*Link
+		var pending *pendingLog // data is logged only for the durable Link taking it
+		routes := 0
+		refill := time.Duration(-1)
+		for _, link := range n.egress[node.Key()] {
+			if link.removed.Load() || link.deadLetter {
+				continue
+			}
+			routes++
+			if link.paused.Load() {
+				continue
+			}
+			if link.limiter != nil {
+				ok, wait := link.limiter.allow()
+				if !ok {
+					if refill < 0 || wait < refill {
+						refill = wait
+					}
+					continue
+				}
 			}
+			lp := p
+			if link.durable != nil {
+				if pending == nil {
+					pending = &pendingLog{done: make(chan struct{})}
+				}
+				lp.logging = pending
+			}
+			links = append(links, link)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(link.ch), Send: reflect.ValueOf(lp)})
 		}
+		n.mu.RUnlock()
 
-		nodeWg, nodeCtx := errgroup.WithContext(ctx)
+		if routes == 0 {
+			n.log().Debug("No route for data", "node", node.Key(), n.payload(p.data))
+			return true
+		}
 
-		for _, ingressLink := range ingress {
-			nodeWg.Go(func() error {
-				for {
-					select {
-					case <-nodeCtx.Done():
-						n.log("Terminal(%s/%s) node-ctx done for Node(%s)", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
-						return nil
-					case inData, ok := <-ingressLink.ch:
-						if !ok {
-							n.log("Terminal(%s/%s) To Node(%s) Link Closed", node.Key(), ingressLink.y.Key(), ingressLink.x.Key())
-							return nil
-						}
-						ingressLink.tally++
-						n.log("Terminal(%s/%s) Received Data(%v) From(%s)", node.Key(), ingressLink.y.Key(), inData, ingressLink.x.Key())
+		var timer *time.Timer
+		if refill >= 0 {
+			timer = time.NewTimer(refill)
+			cases[2].Chan = reflect.ValueOf(timer.C)
+		}
 
-						nodeErr := nf(nodeCtx, inData, func(any) {})
-						if nodeErr != nil {
-							if errors.Is(nodeErr, ErrNodeGoingAway) {
-								n.log("Terminal(%s/%s) %v for Node(%s)", node.Key(), ingressLink.y.Key(), nodeErr, ingressLink.x.Key())
-								return nil
-							}
-							n.log("Terminal(%s/%s) Err: %v for Node(%s)", node.Key(), ingressLink.y.Key(), nodeErr, ingressLink.x.Key())
-							return nodeErr
-						}
-						n.log("Terminal(%s/%s) Consumed Data(%v) for Node(%s)", node.Key(), ingressLink.y.Key(), inData, ingressLink.x.Key())
+		n.log().Debug("Distributing data", "node", node.Key(), "to", linkYs(links), n.payload(p.data))
+		// hold a count for the delivery before data is handed over
+		p.ack.add()
+		waited := time.Now()
+		chosen, _, _ := reflect.Select(cases)
+		if timer != nil {
+			timer.Stop()
+		}
+		for i, link := range links {
+			if i == chosen-3 {
+				continue
+			}
+			// give back the limits taken by Link(s) not chosen
+			if link.limiter != nil {
+				link.limiter.refund()
+			}
+		}
+		switch chosen {
+		case 0:
+			p.ack.done(ErrDataDropped)
+			return false
+		case 1, 2:
+			// Network changed or limits refilled, pick from the refreshed egress Link(s)
+			p.ack.done(nil)
+			continue
+		default:
+			link := links[chosen-3]
+			if link.durable != nil {
+				n.logPending(link, p, pending)
+			}
+			link.blocked.Add(int64(time.Since(waited)))
+			n.log().Debug("Distributed data", "node", node.Key(), linkAttr(link), n.payload(p.data))
+			return true
+		}
+	}
+}
+
+// send sends data over the Link.
+// Sending waits while the Link is paused, and data is skipped once the Link is removed.
+// When the Link is full, data is held or dropped according to the backpressure of the Link.
+// Checkpoint barriers are always held, and they are not subject to the rate limit of the Link.
+// Data is logged for durable Link before it is sent, and it is always held.
+// It returns false if ctx is done before data is sent.
+func (n *Network) send(ctx context.Context, link *Link, p packet) bool {
+	barrier := p.barrier != 0
+	if !barrier && link.limiter != nil && !link.limiter.wait(ctx) {
+		return false
+	}
+	hold := barrier || link.durable != nil
+	// data is logged once, data that can't be logged is sent as is
+	if p.offset == 0 {
+		p = n.logged(link, p)
+	}
+
+	var expired <-chan time.Time
+	for {
+		n.mu.RLock()
+		paused, removed, changed := link.paused.Load(), link.removed.Load(), n.changed
+		n.mu.RUnlock()
+
+		switch {
+		case removed:
+			n.log().Debug("Skipped data to removed link", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+			if p.offset > 0 {
+				n.commit(link, p.offset)
+			}
+			return true
+		case paused:
+			n.log().Debug("Holding data for paused link", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+			select {
+			case <-ctx.Done():
+				return false
+			case <-changed:
+				continue
+			}
+		}
+
+		n.log().Debug("Sending data", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+		// hold a count for the delivery before data is handed over
+		p.ack.add()
+		waited := time.Now()
+
+		if bp := link.backpressure; bp.mode != blockMode && !hold {
+			select {
+			case link.ch <- p:
+				n.log().Debug("Sent data", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+				return true
+			default:
+			}
+
+			// Link is full
+			switch bp.mode {
+			case dropNewestMode:
+				n.drop(link, p)
+				return true
+			case dropOldestMode:
+				if cap(link.ch) == 0 {
+					n.drop(link, p)
+					return true
+				}
+				select {
+				case old, ok := <-link.ch:
+					if ok && old.barrier != 0 {
+						// the checkpoint is abandoned in favor of the next one
+						n.log().Debug("Evicted checkpoint barrier", "node", link.x.Key(), linkAttr(link), "checkpoint", old.barrier)
+					} else if ok {
+						n.drop(link, old)
 					}
+				default:
 				}
-			})
+				// try again with the room made
+				p.ack.done(nil)
+				continue
+			case sampleMode:
+				if link.sampled.Add(1)%int64(bp.every) != 0 {
+					n.drop(link, p)
+					return true
+				}
+			}
+		} else if bp.timeout > 0 && expired == nil && !hold {
+			timer := time.NewTimer(bp.timeout)
+			defer timer.Stop()
+			expired = timer.C
 		}
 
-		if err := nodeWg.Wait(); err != nil {
-			return err
+		select {
+		case <-ctx.Done():
+			p.ack.done(ErrDataDropped)
+			return false
+		case <-changed:
+			link.blocked.Add(int64(time.Since(waited)))
+			p.ack.done(nil)
+			continue
+		case <-expired:
+			link.blocked.Add(int64(time.Since(waited)))
+			n.drop(link, p)
+			return true
+		case link.ch <- p:
+			link.blocked.Add(int64(time.Since(waited)))
+			n.log().Debug("Sent data", "node", link.x.Key(), linkAttr(link), n.payload(p.data))
+			return true
 		}
 	}
-
-	return nil
 }
 
-// refreshNodes renews the nodeSession and opens all outgoing Link(s) for all the Node(s).
+// refreshNodes renews the session and metrics, and opens all outgoing Link(s) for all the Node(s).
 func (n *Network) refreshNodes() {
-	for _, node := range n.Nodes() {
-		node.session = nodeSession{}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, node := range n.nodes {
+		node.session.reset()
+		node.metrics.reset()
 		n.refreshEgress(node)
 	}
 }
+
+// role returns the role of the Node in the session, for logging.
+func (n *Network) role(node *Node, run *nodeRun) string {
+	if run.seed {
+		return "seed"
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.egress[node.Key()]) == 0 {
+		return "terminal"
+	}
+	return "transit"
+}
+
+func linkYs(links []*Link) string {
+	var ys []string
+	for _, link := range links {
+		ys = append(ys, link.y.Key())
+	}
+	return strings.Join(ys, ",")
+}
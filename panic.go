@@ -0,0 +1,67 @@
+package glow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError captures a panic recovered from the Node function of the Node identified by the key.
+// PanicError matches ErrNodePanicked, as well as the panic value when it is an error.
+type PanicError struct {
+	Key   string
+	Value any    // value the Node function panicked with
+	Data  any    // incoming data the Node function panicked for, nil for seed-node
+	Stack []byte // stack trace of the goroutine that panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("node %s panicked: %v", e.Key, e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrNodePanicked, err}
+	}
+	return []error{ErrNodePanicked}
+}
+
+// OnPanic sets the error policy for the Node when its Node function panics.
+// Panics are always recovered and turned into PanicError, which stops the Network by default.
+// The policy applies to Node(s) with incoming data, panics of seed-nodes stop the Network.
+// See:
+//   - OnError
+func OnPanic(p ErrorPolicy) NodeOpt {
+	return func(n *Node) {
+		n.onPanic = p
+	}
+}
+
+// recovered wraps the Node function to turn panics into PanicError.
+func recovered(node *Node, nf func(context.Context, any, func(any)) error) func(context.Context, any, func(any)) error {
+	if nf == nil {
+		return nil
+	}
+	return func(ctx context.Context, in any, emit func(any)) (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = &PanicError{
+					Key:   node.Key(),
+					Value: v,
+					Data:  in,
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+		return nf(ctx, in, emit)
+	}
+}
+
+// errorPolicy returns the error policy of the Node for the error.
+func (n *Node) errorPolicy(err error) ErrorPolicy {
+	if errors.Is(err, ErrNodePanicked) {
+		return n.onPanic
+	}
+	return n.onError
+}
@@ -0,0 +1,613 @@
+package glow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseDOT builds a Network from the DOT description of a directed graph.
+// Node functions are resolved from the registry of named node functions, either by the "func"
+// attribute of the node or by the node key when the attribute is not set.
+//
+// Following attributes are honored:
+//   - Node "distributor" set to true enables distributor mode for the Node.
+//   - Node "strategy" sets how the distributor picks the Link for data, one of "round-robin", "weighted",
+//     "least-queued" or "random" (see RoundRobin, Weighted, LeastQueued, Random).
+//   - Node "workers" sets the count of workers for the Node, and "ordered" set to true keeps their output in order.
+//   - Edge "size" sets bandwidth for the Link.
+//   - Edge "backpressure" sets what happens when the Link is full, one of "block", "block:<timeout>",
+//     "drop-newest", "drop-oldest" or "sample:<k>" (see Block, BlockFor, DropNewest, DropOldest, Sample).
+//   - Edge "weight" sets the weight of the Link for the weighted distribution.
+//   - Edge "deadletter" set to true marks the Link as a dead-letter Link.
+//   - Edge "durable" backs the Link with a log in the directory it is set to (see Durable).
+//
+// Other attributes, e.g. the ones for styling, are ignored. Networks described by DOT are parsable.
+func ParseDOT(data []byte, registry map[string]NodeOpt, opt ...NetworkOpt) (*Network, error) {
+	p := &dotParser{
+		lex:   &dotLexer{src: []rune(string(data)), line: 1},
+		attrs: make(map[string]map[string]string),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+
+	net := New(opt...)
+
+	for _, key := range p.nodes {
+		attrs := p.attrs[key]
+
+		name := key
+		if f, ok := attrs["func"]; ok {
+			name = f
+		}
+		nf, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNodeFunctionMissing, name)
+		}
+
+		nodeOpts := []NodeOpt{Key(key), nf}
+		if v, ok := attrs["distributor"]; ok {
+			distributor, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: node %s distributor %q", ErrInvalidDOT, key, v)
+			}
+			if distributor {
+				nodeOpts = append(nodeOpts, Distributor())
+			}
+		}
+		if v := attrs["strategy"]; v != "" {
+			strategy, ok := parseStrategy(v)
+			if !ok {
+				return nil, fmt.Errorf("%w: node %s strategy %q", ErrInvalidDOT, key, v)
+			}
+			nodeOpts = append(nodeOpts, strategy)
+		}
+		if v, ok := attrs["workers"]; ok {
+			workers, err := strconv.Atoi(v)
+			if err != nil || workers < 1 {
+				return nil, fmt.Errorf("%w: node %s workers %q", ErrInvalidDOT, key, v)
+			}
+			nodeOpts = append(nodeOpts, Workers(workers))
+		}
+		if v, ok := attrs["ordered"]; ok {
+			ordered, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: node %s ordered %q", ErrInvalidDOT, key, v)
+			}
+			if ordered {
+				nodeOpts = append(nodeOpts, Ordered())
+			}
+		}
+
+		if _, err := net.AddNode(nodeOpts...); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, key)
+		}
+	}
+
+	for _, edge := range p.edges {
+		var linkOpts []LinkOpt
+		if v, ok := edge.attrs["size"]; ok {
+			size, err := strconv.Atoi(v)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("%w: edge %s -> %s size %q", ErrInvalidDOT, edge.from, edge.to, v)
+			}
+			linkOpts = append(linkOpts, Size(size))
+		}
+		if v, ok := edge.attrs["backpressure"]; ok {
+			bp, ok := parseBackpressure(v)
+			if !ok {
+				return nil, fmt.Errorf("%w: edge %s -> %s backpressure %q", ErrInvalidDOT, edge.from, edge.to, v)
+			}
+			linkOpts = append(linkOpts, bp)
+		}
+		if v, ok := edge.attrs["weight"]; ok {
+			weight, err := strconv.Atoi(v)
+			if err != nil || weight < 1 {
+				return nil, fmt.Errorf("%w: edge %s -> %s weight %q", ErrInvalidDOT, edge.from, edge.to, v)
+			}
+			linkOpts = append(linkOpts, Weight(weight))
+		}
+		if v, ok := edge.attrs["deadletter"]; ok {
+			deadLetter, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: edge %s -> %s deadletter %q", ErrInvalidDOT, edge.from, edge.to, v)
+			}
+			if deadLetter {
+				linkOpts = append(linkOpts, DeadLetter())
+			}
+		}
+		if v := edge.attrs["durable"]; v != "" {
+			linkOpts = append(linkOpts, Durable(v))
+		}
+
+		if err := net.AddLink(edge.from, edge.to, linkOpts...); err != nil {
+			return nil, fmt.Errorf("%w: %s -> %s", err, edge.from, edge.to)
+		}
+	}
+
+	return net, nil
+}
+
+type dotEdge struct {
+	from  string
+	to    string
+	attrs map[string]string
+}
+
+// dotParser parses the subset of the DOT language describing nodes, edges and their attributes.
+// Subgraphs are flattened, and ports are ignored.
+type dotParser struct {
+	lex   *dotLexer
+	tok   dotToken
+	nodes []string                     // node keys in order of appearance
+	attrs map[string]map[string]string // node attributes
+	edges []dotEdge
+}
+
+type dotScope struct {
+	node map[string]string // default node attributes
+	edge map[string]string // default edge attributes
+}
+
+func (p *dotParser) parse() error {
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	if p.keyword("strict") {
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+	if !p.keyword("digraph") {
+		return p.errorf("expected digraph")
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+	if p.tok.kind == dotID {
+		// graph name
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.block(&dotScope{node: map[string]string{}, edge: map[string]string{}}); err != nil {
+		return err
+	}
+
+	if p.tok.kind != dotEOF {
+		return p.errorf("unexpected %q after graph", p.tok.text)
+	}
+
+	return nil
+}
+
+// block parses statements between braces.
+func (p *dotParser) block(parent *dotScope) error {
+	if !p.punct("{") {
+		return p.errorf("expected {")
+	}
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	// defaults set within the block don't leak out of it
+	scope := &dotScope{node: cloneAttrs(parent.node), edge: cloneAttrs(parent.edge)}
+
+	for !p.punct("}") {
+		if p.tok.kind == dotEOF {
+			return p.errorf("expected }")
+		}
+		if err := p.stmt(scope); err != nil {
+			return err
+		}
+		if p.punct(";") {
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.next()
+}
+
+func (p *dotParser) stmt(scope *dotScope) error {
+	switch {
+	case p.keyword("graph"), p.keyword("node"), p.keyword("edge"):
+		kind := strings.ToLower(p.tok.text)
+		if err := p.next(); err != nil {
+			return err
+		}
+		attrs, err := p.attrList()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case "node":
+			mergeAttrs(scope.node, attrs)
+		case "edge":
+			mergeAttrs(scope.edge, attrs)
+		}
+		return nil
+	case p.keyword("subgraph"):
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.kind == dotID {
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+		return p.block(scope)
+	case p.punct("{"):
+		return p.block(scope)
+	case p.tok.kind != dotID:
+		return p.errorf("unexpected %q", p.tok.text)
+	}
+
+	id := p.tok.text
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	if p.punct("=") {
+		// graph attribute
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.kind != dotID {
+			return p.errorf("expected value for %s", id)
+		}
+		return p.next()
+	}
+
+	if err := p.port(); err != nil {
+		return err
+	}
+
+	ids := []string{id}
+	for p.punct("->") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.kind != dotID {
+			return p.errorf("expected node after ->")
+		}
+		ids = append(ids, p.tok.text)
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.port(); err != nil {
+			return err
+		}
+	}
+	if p.punct("--") {
+		return p.errorf("undirected edges are not supported")
+	}
+
+	attrs, err := p.attrList()
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 1 {
+		p.node(id, scope.node, attrs)
+		return nil
+	}
+
+	for i := 0; i < len(ids)-1; i++ {
+		p.node(ids[i], scope.node, nil)
+		p.node(ids[i+1], scope.node, nil)
+		edgeAttrs := cloneAttrs(scope.edge)
+		mergeAttrs(edgeAttrs, attrs)
+		p.edges = append(p.edges, dotEdge{from: ids[i], to: ids[i+1], attrs: edgeAttrs})
+	}
+
+	return nil
+}
+
+// node records the node with given attributes, defaults apply only when node is first seen.
+func (p *dotParser) node(id string, defaults, attrs map[string]string) {
+	nodeAttrs, ok := p.attrs[id]
+	if !ok {
+		p.nodes = append(p.nodes, id)
+		nodeAttrs = cloneAttrs(defaults)
+		p.attrs[id] = nodeAttrs
+	}
+	mergeAttrs(nodeAttrs, attrs)
+}
+
+// port skips the port of the node, if any.
+func (p *dotParser) port() error {
+	for p.punct(":") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.kind != dotID {
+			return p.errorf("expected port")
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attrList parses zero or more bracketed attribute lists.
+func (p *dotParser) attrList() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.punct("[") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.punct("]") {
+			if p.tok.kind != dotID {
+				return nil, p.errorf("expected attribute name")
+			}
+			name := p.tok.text
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if !p.punct("=") {
+				return nil, p.errorf("expected = after %s", name)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind != dotID {
+				return nil, p.errorf("expected value for %s", name)
+			}
+			attrs[name] = p.tok.text
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.punct(",") || p.punct(";") {
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return attrs, nil
+}
+
+func (p *dotParser) next() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *dotParser) keyword(k string) bool {
+	return p.tok.kind == dotID && !p.tok.quoted && strings.EqualFold(p.tok.text, k)
+}
+
+func (p *dotParser) punct(s string) bool {
+	return p.tok.kind == dotPunct && p.tok.text == s
+}
+
+func (p *dotParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrInvalidDOT, p.tok.line, fmt.Sprintf(format, a...))
+}
+
+type dotTokenKind int
+
+const (
+	dotEOF dotTokenKind = iota
+	dotID
+	dotPunct
+)
+
+type dotToken struct {
+	kind   dotTokenKind
+	text   string
+	quoted bool
+	line   int
+}
+
+type dotLexer struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (l *dotLexer) next() (dotToken, error) {
+	if err := l.skip(); err != nil {
+		return dotToken{}, err
+	}
+	if l.pos >= len(l.src) {
+		return dotToken{kind: dotEOF, line: l.line}, nil
+	}
+
+	line := l.line
+	r := l.src[l.pos]
+
+	switch {
+	case r == '"':
+		text, err := l.quoted()
+		if err != nil {
+			return dotToken{}, err
+		}
+		// concatenation of quoted strings
+		for {
+			mark, markLine := l.pos, l.line
+			if err = l.skip(); err != nil {
+				return dotToken{}, err
+			}
+			if l.pos < len(l.src) && l.src[l.pos] == '+' {
+				l.pos++
+				if err = l.skip(); err != nil {
+					return dotToken{}, err
+				}
+				if l.pos < len(l.src) && l.src[l.pos] == '"' {
+					more, err := l.quoted()
+					if err != nil {
+						return dotToken{}, err
+					}
+					text += more
+					continue
+				}
+			}
+			l.pos, l.line = mark, markLine
+			break
+		}
+		return dotToken{kind: dotID, text: text, quoted: true, line: line}, nil
+	case r == '<':
+		text, err := l.html()
+		if err != nil {
+			return dotToken{}, err
+		}
+		return dotToken{kind: dotID, text: text, quoted: true, line: line}, nil
+	case r == '-' && l.pos+1 < len(l.src) && (l.src[l.pos+1] == '>' || l.src[l.pos+1] == '-'):
+		l.pos += 2
+		return dotToken{kind: dotPunct, text: string(l.src[l.pos-2 : l.pos]), line: line}, nil
+	case strings.ContainsRune("{}[]=;,:", r):
+		l.pos++
+		return dotToken{kind: dotPunct, text: string(r), line: line}, nil
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-':
+		start := l.pos
+		for l.pos < len(l.src) {
+			r = l.src[l.pos]
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' {
+				l.pos++
+				continue
+			}
+			if r == '-' && l.pos == start {
+				// negative numeral
+				l.pos++
+				continue
+			}
+			break
+		}
+		return dotToken{kind: dotID, text: string(l.src[start:l.pos]), line: line}, nil
+	default:
+		return dotToken{}, fmt.Errorf("%w: line %d: unexpected %q", ErrInvalidDOT, line, r)
+	}
+}
+
+// skip skips whitespaces and comments.
+func (l *dotLexer) skip() error {
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case r == '\n':
+			l.line++
+			l.pos++
+		case unicode.IsSpace(r):
+			l.pos++
+		case r == '#' && l.atLineStart(), r == '/' && l.peek(1) == '/':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case r == '/' && l.peek(1) == '*':
+			l.pos += 2
+			for {
+				if l.pos >= len(l.src) {
+					return fmt.Errorf("%w: line %d: unterminated comment", ErrInvalidDOT, l.line)
+				}
+				if l.src[l.pos] == '*' && l.peek(1) == '/' {
+					l.pos += 2
+					break
+				}
+				if l.src[l.pos] == '\n' {
+					l.line++
+				}
+				l.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *dotLexer) quoted() (string, error) {
+	line := l.line
+	l.pos++ // opening quote
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		switch {
+		case r == '"':
+			l.pos++
+			return sb.String(), nil
+		case r == '\\' && l.peek(1) == '"':
+			sb.WriteRune('"')
+			l.pos += 2
+		case r == '\\' && l.peek(1) == '\n':
+			// line continuation
+			l.line++
+			l.pos += 2
+		default:
+			if r == '\n' {
+				l.line++
+			}
+			sb.WriteRune(r)
+			l.pos++
+		}
+	}
+	return "", fmt.Errorf("%w: line %d: unterminated string", ErrInvalidDOT, line)
+}
+
+func (l *dotLexer) html() (string, error) {
+	line := l.line
+	depth := 0
+	start := l.pos
+	for l.pos < len(l.src) {
+		r := l.src[l.pos]
+		l.pos++
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return string(l.src[start+1 : l.pos-1]), nil
+			}
+		case '\n':
+			l.line++
+		}
+	}
+	return "", fmt.Errorf("%w: line %d: unterminated HTML string", ErrInvalidDOT, line)
+}
+
+func (l *dotLexer) peek(k int) rune {
+	if l.pos+k < len(l.src) {
+		return l.src[l.pos+k]
+	}
+	return 0
+}
+
+func (l *dotLexer) atLineStart() bool {
+	for i := l.pos - 1; i >= 0; i-- {
+		switch l.src[i] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+			continue
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func cloneAttrs(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	mergeAttrs(c, m)
+	return c
+}
+
+func mergeAttrs(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
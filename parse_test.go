@@ -0,0 +1,217 @@
+package glow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDOT(t *testing.T) {
+	dir := t.TempDir()
+	nf := BasicFunc(func(ctx context.Context, data any) (any, error) {
+		return data, nil
+	})
+	registry := map[string]NodeOpt{"a": nf, "b": nf, "c": nf, "f": nf}
+
+	tests := []struct {
+		name string
+		dot  string
+		want []string
+		err  error
+	}{
+		{
+			name: "edge",
+			dot:  `digraph { a -> b }`,
+			want: []string{"a", "b", "a->b"},
+		},
+		{
+			name: "strict with name and graph attributes",
+			dot:  `strict digraph net { rankdir=LR; graph [label="x"]; a -> b; }`,
+			want: []string{"a", "b", "a->b"},
+		},
+		{
+			name: "chained edges with ports",
+			dot:  `digraph { a:out -> b:in:n -> c }`,
+			want: []string{"a", "b", "c", "a->b", "b->c"},
+		},
+		{
+			name: "node function by attribute",
+			dot:  `digraph { x [func=f]; a -> x }`,
+			want: []string{"a", "x", "a->x"},
+		},
+		{
+			name: "node attributes",
+			dot: `digraph {
+				a [distributor=true, strategy="weighted", workers=4, ordered=true]
+				a -> b [weight=3]
+				a -> c
+			}`,
+			want: []string{"a distributor strategy=weighted workers=4 ordered", "b", "c", "a->b weight=3", "a->c"},
+		},
+		{
+			name: "edge attributes",
+			dot: `digraph {
+				a -> b [size=10 backpressure="block:1s"]
+				b -> c [size=2; backpressure="drop-oldest"]
+				a -> c [backpressure="sample:5"]
+				c -> f [deadletter=true]
+			}`,
+			want: []string{"a", "b", "c", "f",
+				"a->b size=10 backpressure=block:1s", "a->c backpressure=sample:5",
+				"b->c size=2 backpressure=drop-oldest", "c->f deadletter"},
+		},
+		{
+			name: "durable edge",
+			dot:  fmt.Sprintf(`digraph { a -> b [durable=%q] }`, filepath.Join(dir, "a-b")),
+			want: []string{"a", "b", "a->b durable"},
+		},
+		{
+			name: "defaults don't leak out of subgraph",
+			dot: `digraph {
+				subgraph cluster { node [distributor=true]; edge [size=5]; a -> b }
+				a -> c
+			}`,
+			want: []string{"a distributor", "b distributor", "c", "a->b size=5", "a->c"},
+		},
+		{
+			name: "defaults apply to nodes first seen",
+			dot:  `digraph { a; node [distributor=true]; a -> b }`,
+			want: []string{"a", "b distributor", "a->b"},
+		},
+		{
+			name: "comments and concatenated strings",
+			dot: `// network
+				# preprocessor line
+				digraph { /* block
+				comment */ "a" -> "b" [backpressure="drop-" + "newest"] }`,
+			want: []string{"a", "b", "a->b backpressure=drop-newest"},
+		},
+		{
+			name: "html strings are ignored",
+			dot:  `digraph { a [label=<<b>a</b>>]; a -> b }`,
+			want: []string{"a", "b", "a->b"},
+		},
+		{name: "not digraph", dot: `graph { a -- b }`, err: ErrInvalidDOT},
+		{name: "undirected edge", dot: `digraph { a -- b }`, err: ErrInvalidDOT},
+		{name: "missing brace", dot: `digraph { a -> b`, err: ErrInvalidDOT},
+		{name: "trailing data", dot: `digraph { a -> b } c`, err: ErrInvalidDOT},
+		{name: "unterminated string", dot: `digraph { "a -> b }`, err: ErrInvalidDOT},
+		{name: "unterminated comment", dot: `digraph { a -> b /* }`, err: ErrInvalidDOT},
+		{name: "unexpected character", dot: `digraph { a -> b @ }`, err: ErrInvalidDOT},
+		{name: "missing attribute value", dot: `digraph { a -> b [size=] }`, err: ErrInvalidDOT},
+		{name: "invalid distributor", dot: `digraph { a [distributor=maybe]; a -> b }`, err: ErrInvalidDOT},
+		{name: "invalid strategy", dot: `digraph { a [strategy=fastest]; a -> b }`, err: ErrInvalidDOT},
+		{name: "invalid workers", dot: `digraph { a [workers=0]; a -> b }`, err: ErrInvalidDOT},
+		{name: "invalid size", dot: `digraph { a -> b [size=-1] }`, err: ErrInvalidDOT},
+		{name: "invalid backpressure", dot: `digraph { a -> b [backpressure=spill] }`, err: ErrInvalidDOT},
+		{name: "invalid weight", dot: `digraph { a -> b [weight=0] }`, err: ErrInvalidDOT},
+		{name: "missing node function", dot: `digraph { a -> z }`, err: ErrNodeFunctionMissing},
+		{name: "duplicate edge", dot: `digraph { a -> b; a -> b }`, err: ErrLinkAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			net, err := ParseDOT([]byte(tt.dot), registry)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			got := describe(net)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			for _, link := range net.Links() {
+				if link.durable != nil {
+					link.durable.close()
+				}
+			}
+		})
+	}
+}
+
+func TestParseDOTRoundTrip(t *testing.T) {
+	nf := BasicFunc(func(ctx context.Context, data any) (any, error) {
+		return data, nil
+	})
+	registry := map[string]NodeOpt{"a": nf, "b": nf, "c": nf}
+
+	net := New()
+	for _, opt := range [][]NodeOpt{{Key("a"), nf, LeastQueued()}, {Key("b"), nf, Workers(2)}, {Key("c"), nf}} {
+		if _, err := net.AddNode(opt...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := net.AddLink("a", "b", Size(3), DropNewest()); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.AddLink("a", "c", Weight(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.AddLink("b", "c", DeadLetter()); err != nil {
+		t.Fatal(err)
+	}
+
+	dot, err := DOT(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseDOT(dot, registry)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", dot, err)
+	}
+	if got, want := describe(parsed), describe(net); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// describe lists the nodes and the links of the Network with the attributes ParseDOT honors, in the order of keys.
+func describe(net *Network) []string {
+	var nodes, links []string
+	for _, node := range net.Nodes() {
+		d := []string{node.Key()}
+		if node.distributor {
+			d = append(d, "distributor")
+		}
+		if node.strategy != nil {
+			d = append(d, "strategy="+node.strategy.String())
+		}
+		if node.workers > 1 {
+			d = append(d, fmt.Sprintf("workers=%d", node.workers))
+		}
+		if node.ordered {
+			d = append(d, "ordered")
+		}
+		nodes = append(nodes, strings.Join(d, " "))
+	}
+	for _, link := range net.Links() {
+		d := []string{link.x.Key() + "->" + link.y.Key()}
+		if link.size > 0 {
+			d = append(d, fmt.Sprintf("size=%d", link.size))
+		}
+		if bp := link.backpressure.String(); bp != "block" {
+			d = append(d, "backpressure="+bp)
+		}
+		if link.weight > 1 {
+			d = append(d, fmt.Sprintf("weight=%d", link.weight))
+		}
+		if link.deadLetter {
+			d = append(d, "deadletter")
+		}
+		if link.durable != nil {
+			d = append(d, "durable")
+		}
+		links = append(links, strings.Join(d, " "))
+	}
+	slices.Sort(nodes)
+	slices.Sort(links)
+	return append(nodes, links...)
+}
@@ -0,0 +1,23 @@
+package glow
+
+import (
+	"hash/fnv"
+)
+
+// Partition enables a Node to partition outgoing data among its egress Link(s) by the key taken from the data
+// with the key function. Data with the same key always goes over the same Link, which keeps the order of data
+// per key and lets downstream nodes own a range of keys. Egress Link(s) are ordered by to-node key, and keys are
+// reassigned when Link(s) are added or removed while the Network is running. Data for a paused Link is held.
+func Partition(kf func(data any) string) NodeOpt {
+	return func(n *Node) {
+		n.partition = kf
+	}
+}
+
+// KeyPartition returns the partition of the key out of n partitions, which Partition sends the data with the key to.
+// It is the same for a key across processes and sessions.
+func KeyPartition(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
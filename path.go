@@ -0,0 +1,213 @@
+package glow
+
+import (
+	"cmp"
+	"golang.org/x/exp/maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Path captures the route data takes from a seed-node to a terminal-node over the Link(s).
+type Path struct {
+	links []*Link
+}
+
+// Links returns the Link(s) along the Path in the direction of data flow.
+func (p Path) Links() []*Link {
+	return p.links
+}
+
+// Keys returns the keys of the nodes along the Path in the direction of data flow.
+func (p Path) Keys() []string {
+	if len(p.links) == 0 {
+		return nil
+	}
+	keys := []string{p.links[0].x.Key()}
+	for _, link := range p.links {
+		keys = append(keys, link.y.Key())
+	}
+	return keys
+}
+
+// Tally returns the count of data transmitted over the Path thus far,
+// which is bound by the least used Link along the Path.
+func (p Path) Tally() int {
+	if len(p.links) == 0 {
+		return 0
+	}
+	tally := math.MaxInt
+	for _, link := range p.links {
+		tally = min(tally, link.Tally())
+	}
+	return tally
+}
+
+// Throughput returns the count of data transmitted over the Path per second,
+// which is bound by the slowest Link along the Path.
+func (p Path) Throughput() float64 {
+	if len(p.links) == 0 {
+		return 0
+	}
+	throughput := math.MaxFloat64
+	for _, link := range p.links {
+		uptime := link.Uptime()
+		if uptime <= 0 {
+			return 0
+		}
+		throughput = min(throughput, float64(link.Tally())/uptime.Seconds())
+	}
+	return throughput
+}
+
+// Latency returns the time data takes to go over the Path,
+// which is the sum of the latencies of the nodes along the Path.
+// See:
+//   - Node.Latency
+func (p Path) Latency() time.Duration {
+	var latency time.Duration
+	for _, link := range p.links {
+		latency += link.y.Latency()
+	}
+	return latency
+}
+
+func (p Path) String() string {
+	return strings.Join(p.Keys(), " -> ")
+}
+
+// Paths returns all the paths from seed-nodes to terminal-nodes in the Network.
+// Every route is a Path of its own, so the count of paths grows fast with fan-out, e.g. across replicas.
+// Removed and dead-letter Link(s) are not part of any Path, and Link(s) closing cycles are not followed.
+func (n *Network) Paths() []Path {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	var paths []Path
+
+	var walk func(key string, visited map[string]bool, links []*Link)
+	walk = func(key string, visited map[string]bool, links []*Link) {
+		visited[key] = true
+		defer delete(visited, key)
+
+		next := 0
+		for _, link := range n.egress[key] {
+			if link.removed.Load() || link.deadLetter || visited[link.y.Key()] {
+				continue
+			}
+			next++
+			walk(link.y.Key(), visited, append(slices.Clip(links), link))
+		}
+
+		if next == 0 && len(links) > 0 {
+			paths = append(paths, Path{links: links})
+		}
+	}
+
+	for key := range n.nodes {
+		if len(n.ingress[key]) == 0 && len(n.egress[key]) > 0 {
+			walk(key, make(map[string]bool), nil)
+		}
+	}
+
+	return paths
+}
+
+// PathsByUsage returns all the paths in the Network ordered by throughput,
+// the most used Path comes first and the least used Path comes last.
+func (n *Network) PathsByUsage() []Path {
+	type usage struct {
+		path       Path
+		throughput float64
+		tally      int
+	}
+	paths := n.Paths()
+	usages := make([]usage, len(paths))
+	for i, p := range paths {
+		usages[i] = usage{path: p, throughput: p.Throughput(), tally: p.Tally()}
+	}
+	slices.SortStableFunc(usages, func(a, b usage) int {
+		switch {
+		case a.throughput > b.throughput:
+			return -1
+		case a.throughput < b.throughput:
+			return 1
+		default:
+			return b.tally - a.tally
+		}
+	})
+	for i, u := range usages {
+		paths[i] = u.path
+	}
+	return paths
+}
+
+// PathsByLatency returns all the paths in the Network ordered by latency,
+// the fastest Path comes first and the slowest Path comes last.
+func (n *Network) PathsByLatency() []Path {
+	type latency struct {
+		path    Path
+		latency time.Duration
+	}
+	paths := n.Paths()
+	latencies := make([]latency, len(paths))
+	for i, p := range paths {
+		latencies[i] = latency{path: p, latency: p.Latency()}
+	}
+	slices.SortStableFunc(latencies, func(a, b latency) int {
+		return cmp.Compare(a.latency, b.latency)
+	})
+	for i, l := range latencies {
+		paths[i] = l.path
+	}
+	return paths
+}
+
+// hotPath returns the Path followed from a seed-node over the most used egress Link of every Node,
+// if any data went over it. Unlike Paths, it visits every Node at most once.
+func (n *Network) hotPath() (Path, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	// hottest returns the most used Link out of the Link(s), ties go to the Link first in the order of keys
+	hottest := func(links []*Link, visited map[string]bool) *Link {
+		var picked *Link
+		pickedTally := 0
+		for _, link := range links {
+			if link.removed.Load() || link.deadLetter || visited[link.y.Key()] {
+				continue
+			}
+			tally := link.Tally()
+			if tally == 0 {
+				continue
+			}
+			if picked == nil || tally > pickedTally ||
+				tally == pickedTally && cmp.Or(strings.Compare(link.x.Key(), picked.x.Key()), strings.Compare(link.y.Key(), picked.y.Key())) < 0 {
+				picked, pickedTally = link, tally
+			}
+		}
+		return picked
+	}
+
+	var seedLinks []*Link
+	for key := range n.nodes {
+		if len(n.ingress[key]) == 0 {
+			for _, link := range n.egress[key] {
+				seedLinks = append(seedLinks, link)
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	var links []*Link
+	for link := hottest(seedLinks, visited); link != nil; link = hottest(maps.Values(n.egress[link.y.Key()]), visited) {
+		visited[link.x.Key()] = true
+		visited[link.y.Key()] = true
+		links = append(links, link)
+	}
+	if len(links) == 0 {
+		return Path{}, false
+	}
+	return Path{links: links}, true
+}
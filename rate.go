@@ -0,0 +1,114 @@
+package glow
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket, it is safe for concurrent use.
+// The bucket holds up to burst tokens and is refilled at rate tokens per second.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(r float64, burst int) *limiter {
+	burst = max(burst, 1)
+	return &limiter{
+		rate:   r,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// refill adds tokens accumulated since the last refill. Must be called with limiter.mu held.
+func (l *limiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// wait takes a token, waiting for the bucket to refill if needed.
+// It returns false if ctx is done before the token is available.
+func (l *limiter) wait(ctx context.Context) bool {
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.refund()
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// allow takes a token if one is available right away.
+// Otherwise, it returns how long it takes for a token to be available.
+func (l *limiter) allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// refund puts back a token taken but not used.
+func (l *limiter) refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// limit returns the rate of the limiter, zero for no limiter.
+func (l *limiter) limit() float64 {
+	if l == nil {
+		return 0
+	}
+	return l.rate
+}
+
+// RateLimit limits the Node function calls to r per second, allowing bursts of up to burst calls.
+// For seed-node with EmitFunc, which is called once, emitted data is paced instead.
+// The limit is shared by all the Node(s) given the same NodeOpt.
+func RateLimit(r float64, burst int) NodeOpt {
+	var l *limiter
+	if r > 0 {
+		l = newLimiter(r, burst)
+	}
+	return func(n *Node) {
+		n.limiter = l
+	}
+}
+
+// LinkRateLimit limits data going over the Link to r per second, allowing bursts of up to burst data points.
+// The limit is shared by all the Link(s) given the same LinkOpt.
+func LinkRateLimit(r float64, burst int) LinkOpt {
+	var l *limiter
+	if r > 0 {
+		l = newLimiter(r, burst)
+	}
+	return func(link *Link) {
+		link.limiter = l
+	}
+}
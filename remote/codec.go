@@ -0,0 +1,53 @@
+package remote
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// Codec turns data into bytes going over the connection and back.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type Encoder interface {
+	Encode(v any) error
+}
+
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Gob returns Codec using encoding/gob.
+// Concrete types carried as data, other than basic types, must be registered with gob.Register.
+func Gob() Codec {
+	return gobCodec{}
+}
+
+// JSON returns Codec using encoding/json.
+// Data is decoded as generic JSON values, e.g. numbers are decoded as float64.
+func JSON() Codec {
+	return jsonCodec{}
+}
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
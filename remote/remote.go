@@ -0,0 +1,308 @@
+// Package remote runs Node functions in another process.
+//
+// A remote process serves a Node function on a listener, see Serve.
+// A Node in the Network reaches the Node function over TCP or Unix socket, see EmitFunc.
+// Data going into the Node is sent over the connection, and data emitted by the remote
+// Node function comes back as data emitted by the Node.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lnashier/glow"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrNodeFailed = errors.New("remote node failed")
+
+type frameKind int
+
+const (
+	callFrame frameKind = iota
+	emitFrame
+	doneFrame
+	failFrame
+)
+
+// frame is the unit of exchange over the connection.
+// A call frame is answered with zero or more emit frames followed by either a done or fail frame.
+type frame struct {
+	Kind frameKind
+	Data any
+	Err  string
+}
+
+type Opt func(*options)
+
+type options struct {
+	codec Codec
+	conns int
+}
+
+func (o *options) apply(opt ...Opt) *options {
+	for _, op := range opt {
+		op(o)
+	}
+	return o
+}
+
+func defaultOptions() *options {
+	return &options{
+		codec: Gob(),
+		conns: 4,
+	}
+}
+
+// Encoding sets the Codec used over the connection, Gob by default.
+// Both sides of the connection must use the same Codec.
+func Encoding(c Codec) Opt {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// Conns sets the number of idle connections kept open to the remote process, 4 by default.
+func Conns(k int) Opt {
+	return func(o *options) {
+		if k >= 0 {
+			o.conns = k
+		}
+	}
+}
+
+// Serve accepts connections on the listener and runs the Node function for data coming over them.
+// The Node function is called for every incoming data point, and emitted data is sent back over the connection.
+// The Node function is called with the context of the connection, which is done once the connection is closed,
+// e.g. the call is cut short by the Node, or once ctx is done.
+// Serve returns nil once ctx is done.
+//
+// Basic Node function can be served as:
+//
+//	remote.Serve(ctx, l, func(ctx context.Context, in any, emit func(any)) error {
+//		out, err := f(ctx, in)
+//		if err == nil {
+//			emit(out)
+//		}
+//		return err
+//	})
+func Serve(ctx context.Context, l net.Listener, f func(ctx context.Context, data any, emit func(any)) error, opt ...Opt) error {
+	o := defaultOptions().apply(opt...)
+
+	stop := context.AfterFunc(ctx, func() {
+		l.Close()
+	})
+	defer stop()
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveConn(ctx, conn, f, o)
+		}()
+	}
+}
+
+func serveConn(ctx context.Context, conn net.Conn, f func(context.Context, any, func(any)) error, o *options) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	enc := o.codec.NewEncoder(conn)
+	dec := o.codec.NewDecoder(conn)
+
+	// The connection is read while the Node function runs, so the call is cut short once the connection is closed.
+	calls := make(chan frame)
+	go func() {
+		defer cancel()
+		for {
+			var in frame
+			if err := dec.Decode(&in); err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case calls <- in:
+			}
+		}
+	}()
+
+	for {
+		var in frame
+		select {
+		case <-ctx.Done():
+			return
+		case in = <-calls:
+		}
+
+		var encErr error
+		err := f(ctx, in.Data, func(data any) {
+			if encErr == nil {
+				encErr = enc.Encode(frame{Kind: emitFrame, Data: data})
+			}
+		})
+		if encErr != nil {
+			return
+		}
+
+		out := frame{Kind: doneFrame}
+		if err != nil {
+			out = frame{Kind: failFrame, Err: err.Error()}
+		}
+		if err = enc.Encode(out); err != nil {
+			return
+		}
+	}
+}
+
+// EmitFunc sets the Node function to the one served by the remote process at the address.
+// See:
+//   - Func
+//   - glow.EmitFunc
+func EmitFunc(network, address string, opt ...Opt) glow.NodeOpt {
+	return glow.EmitFunc(Func(network, address, opt...))
+}
+
+// Func returns emit function calling the Node function served by the remote process at the address.
+// Network must be "tcp", "tcp4", "tcp6" or "unix".
+// Errors returned by the remote Node function are wrapped in ErrNodeFailed,
+// except glow.ErrSeedingDone and glow.ErrNodeGoingAway which are returned as is.
+// A call cut short as ctx is done returns glow.ErrNodeGoingAway wrapping the error of ctx,
+// so the data is not reported as processed.
+func Func(network, address string, opt ...Opt) func(ctx context.Context, data any, emit func(any)) error {
+	c := &client{
+		network: network,
+		address: address,
+		options: defaultOptions().apply(opt...),
+	}
+	c.idle = make(chan *conn, c.options.conns)
+	return c.call
+}
+
+type client struct {
+	network string
+	address string
+	options *options
+	idle    chan *conn
+}
+
+type conn struct {
+	net.Conn
+	enc Encoder
+	dec Decoder
+}
+
+func (c *client) call(ctx context.Context, data any, emit func(any)) error {
+	cn, err := c.get(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return goingAway(ctx)
+		}
+		return err
+	}
+
+	// unblock the connection once ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		cn.SetDeadline(time.Unix(1, 0))
+	})
+
+	nodeErr, err := cn.call(data, emit)
+
+	if !stop() {
+		// Network is going away, connection is left in unknown state
+		cn.Close()
+		return goingAway(ctx)
+	}
+	if err != nil {
+		cn.Close()
+		return err
+	}
+
+	c.put(cn)
+	return nodeErr
+}
+
+// goingAway returns the error for the call cut short as ctx is done, the data may not have been processed.
+func goingAway(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", glow.ErrNodeGoingAway, ctx.Err())
+}
+
+func (c *client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	nc, err := (&net.Dialer{}).DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{
+		Conn: nc,
+		enc:  c.options.codec.NewEncoder(nc),
+		dec:  c.options.codec.NewDecoder(nc),
+	}, nil
+}
+
+func (c *client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// call sends data over the connection and emits data coming back until the call is done.
+// It returns error from the remote Node function, and error from the connection.
+func (cn *conn) call(data any, emit func(any)) (nodeErr error, err error) {
+	if err := cn.enc.Encode(frame{Kind: callFrame, Data: data}); err != nil {
+		return nil, err
+	}
+
+	for {
+		var in frame
+		if err := cn.dec.Decode(&in); err != nil {
+			return nil, err
+		}
+
+		switch in.Kind {
+		case emitFrame:
+			emit(in.Data)
+		case doneFrame:
+			return nil, nil
+		case failFrame:
+			return remoteError(in.Err), nil
+		default:
+			return nil, fmt.Errorf("unexpected frame %d", in.Kind)
+		}
+	}
+}
+
+// remoteError turns error message of the remote Node function back into error.
+func remoteError(msg string) error {
+	for _, err := range []error{glow.ErrSeedingDone, glow.ErrNodeGoingAway} {
+		if msg == err.Error() {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNodeFailed, msg)
+}
@@ -0,0 +1,97 @@
+package glow
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy describes how failed Node function calls are retried.
+type RetryPolicy struct {
+	// Attempts is the total count of attempts, including the first one. One or less means no retries.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for every subsequent retry.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries, zero means no cap.
+	MaxBackoff time.Duration
+	// Jitter randomly shortens the wait between retries by up to the given fraction, in [0, 1].
+	Jitter float64
+	// Retryable reports whether the failed call can be retried, all errors but panics (see PanicError)
+	// are retried when it is not set. ErrSeedingDone and ErrNodeGoingAway are never retried.
+	Retryable func(error) bool
+}
+
+// Retry retries failed Node function calls according to the policy.
+// Data emitted by EmitFunc before it failed is forwarded as is, so retried calls may emit it again.
+// For seed-node with EmitFunc, which is called once, the whole emission is retried.
+// See:
+//   - RetryPolicy
+func Retry(p RetryPolicy) NodeOpt {
+	return func(n *Node) {
+		n.retry = &p
+	}
+}
+
+// do calls f until it succeeds or the policy gives up, it returns the error of the last call.
+// ErrNodeGoingAway is returned if ctx is done while waiting to retry.
+func (p *RetryPolicy) do(ctx context.Context, f func() error, retrying func(attempt int, err error)) error {
+	err := f()
+	for attempt := 1; err != nil && attempt < p.Attempts && p.retryable(err); attempt++ {
+		retrying(attempt, err)
+		timer := time.NewTimer(p.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ErrNodeGoingAway
+		case <-timer.C:
+		}
+		err = f()
+	}
+	return err
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrSeedingDone) || errors.Is(err, ErrNodeGoingAway) {
+		return false
+	}
+	if p.Retryable == nil {
+		return !errors.Is(err, ErrNodePanicked)
+	}
+	return p.Retryable(err)
+}
+
+// backoff returns the wait before the retry following the attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for range attempt - 1 {
+		if (p.MaxBackoff > 0 && d >= p.MaxBackoff) || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		d -= time.Duration(jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// retried wraps the Node function to retry failed calls according to the retry policy of the Node.
+func (n *Network) retried(node *Node, log *slog.Logger, nf func(context.Context, any, func(any)) error) func(context.Context, any, func(any)) error {
+	if nf == nil || node.retry == nil || node.retry.Attempts < 2 {
+		return nf
+	}
+	return func(ctx context.Context, in any, emit func(any)) error {
+		return node.retry.do(ctx, func() error {
+			return nf(ctx, in, emit)
+		}, func(attempt int, err error) {
+			node.metrics.retries.Add(1)
+			log.Debug("Retrying node function", "attempt", attempt, "error", err)
+		})
+	}
+}
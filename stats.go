@@ -0,0 +1,229 @@
+package glow
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of the Network metrics.
+// See:
+//   - Network.Stats
+type Stats struct {
+	Running    bool          // whether the Network has nodes running
+	Uptime     time.Duration // uptime of the current or last session
+	Checkpoint int64         // id of the last saved checkpoint, zero for none
+	Nodes      []NodeStats   // ordered by Node key
+	Links      []LinkStats   // ordered by from-node key, then to-node key
+}
+
+// NodeStats captures the metrics of a Node in the current or last session.
+type NodeStats struct {
+	Key     string
+	Uptime  time.Duration
+	In      int64     // count of data points received
+	Out     int64     // count of data points emitted over egress Link(s)
+	Errors  int64     // count of errors returned by the Node function
+	Retries int64     // count of Node function calls retried
+	Rate    float64   // observed Node function calls per second, emitted data for seed-node with EmitFunc
+	Limit   float64   // Node function calls allowed per second, zero for no limit
+	Latency Histogram // time the Node function takes to process incoming data
+}
+
+// LinkStats captures the metrics of a Link.
+type LinkStats struct {
+	From    string
+	To      string
+	Paused  bool
+	Removed bool
+	Uptime  time.Duration
+	Tally   int64         // count of data points transmitted over the Link thus far
+	Dropped int64         // count of data points dropped as the Link was full thus far
+	Depth   int           // count of data points waiting in the Link
+	Size    int           // bandwidth of the Link
+	Blocked time.Duration // time from-node spent waiting to send data over the Link thus far
+	Rate    float64       // observed data points per second over the Link in the current or last session
+	Limit   float64       // data points allowed per second over the Link, zero for no limit
+}
+
+// Histogram captures the distribution of durations over fixed buckets.
+type Histogram struct {
+	Bounds []time.Duration // inclusive upper bounds of the buckets, the last bucket has no bound
+	Counts []int64         // count of durations per bucket, one more than Bounds
+	Count  int64           // count of all durations
+	Sum    time.Duration   // sum of all durations
+}
+
+// Mean returns the average of all durations.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q-quantile, with q in [0, 1].
+// Durations beyond the last bound are reported at the last bound.
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 || len(h.Bounds) == 0 {
+		return 0
+	}
+	rank := max(int64(math.Ceil(q*float64(h.Count))), 1)
+	var seen int64
+	for i, c := range h.Counts[:len(h.Bounds)] {
+		seen += c
+		if seen >= rank {
+			return h.Bounds[i]
+		}
+	}
+	return h.Bounds[len(h.Bounds)-1]
+}
+
+// latencyBounds are the bucket bounds used for latency histograms.
+var latencyBounds = [...]time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// histogram records durations, it is safe for concurrent use.
+type histogram struct {
+	counts [len(latencyBounds) + 1]atomic.Int64
+	count  atomic.Int64
+	sum    atomic.Int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i, _ := slices.BinarySearch(latencyBounds[:], d)
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+}
+
+func (h *histogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.count.Store(0)
+	h.sum.Store(0)
+}
+
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Bounds: slices.Clone(latencyBounds[:]),
+		Counts: make([]int64, len(h.counts)),
+	}
+	for i := range h.counts {
+		s.Counts[i] = h.counts[i].Load()
+		s.Count += s.Counts[i]
+	}
+	s.Sum = time.Duration(h.sum.Load())
+	return s
+}
+
+func (h *histogram) mean() time.Duration {
+	count := h.count.Load()
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum.Load() / count)
+}
+
+// nodeMetrics captures the metrics of a Node, it is safe for concurrent use.
+type nodeMetrics struct {
+	in      atomic.Int64
+	out     atomic.Int64
+	retries atomic.Int64
+	paced   atomic.Int64 // count of Node function calls, or emitted data for seed-node with EmitFunc
+	errors  atomic.Int64
+	latency histogram
+}
+
+func (m *nodeMetrics) reset() {
+	m.in.Store(0)
+	m.out.Store(0)
+	m.retries.Store(0)
+	m.paced.Store(0)
+	m.errors.Store(0)
+	m.latency.reset()
+}
+
+// Stats returns a snapshot of the Network metrics.
+// It is safe to call while the Network is running.
+func (n *Network) Stats() Stats {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	s := Stats{
+		Running: n.running(),
+		Uptime:  n.session.span.uptime(),
+	}
+	if n.checkpoints != nil {
+		s.Checkpoint = n.checkpoints.saved.Load()
+	}
+
+	for _, node := range n.nodes {
+		uptime := node.Uptime()
+		s.Nodes = append(s.Nodes, NodeStats{
+			Key:     node.Key(),
+			Uptime:  uptime,
+			In:      node.metrics.in.Load(),
+			Out:     node.metrics.out.Load(),
+			Errors:  node.metrics.errors.Load(),
+			Retries: node.metrics.retries.Load(),
+			Rate:    perSecond(node.metrics.paced.Load(), uptime),
+			Limit:   node.limiter.limit(),
+			Latency: node.metrics.latency.snapshot(),
+		})
+	}
+	slices.SortFunc(s.Nodes, func(a, b NodeStats) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+
+	for _, outLinks := range n.egress {
+		for _, link := range outLinks {
+			uptime := link.Uptime()
+			s.Links = append(s.Links, LinkStats{
+				From:    link.x.Key(),
+				To:      link.y.Key(),
+				Paused:  link.paused.Load(),
+				Removed: link.removed.Load(),
+				Uptime:  uptime,
+				Tally:   link.tally.Load(),
+				Dropped: link.dropped.Load(),
+				Depth:   len(link.ch),
+				Size:    link.size,
+				Blocked: time.Duration(link.blocked.Load()),
+				Rate:    perSecond(link.recent.Load(), uptime),
+				Limit:   link.limiter.limit(),
+			})
+		}
+	}
+	slices.SortFunc(s.Links, func(a, b LinkStats) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	return s
+}
+
+// perSecond returns the count over the duration as a rate per second.
+func perSecond(count int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(count) / d.Seconds()
+}
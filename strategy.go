@@ -0,0 +1,198 @@
+package glow
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// strategy captures how a distributor Node picks the Link data goes over.
+// Without a strategy, data goes over whichever Link takes it first.
+type strategy struct {
+	mode strategyMode
+
+	mu      sync.Mutex
+	next    int           // in round-robin and least-queued modes, where the next turn starts
+	current map[*Link]int // in weighted mode, current weights of the Link(s)
+}
+
+type strategyMode int
+
+const (
+	roundRobinMode strategyMode = iota
+	weightedMode
+	leastQueuedMode
+	randomMode
+)
+
+// RoundRobin enables a Node to distribute incoming data among its outgoing links in turns.
+// See:
+//   - Distributor
+func RoundRobin() NodeOpt {
+	return distributeBy(roundRobinMode)
+}
+
+// Weighted enables a Node to distribute incoming data among its outgoing links in proportion to their weights,
+// spreading the turns of every Link evenly, e.g. weights 9 and 1 send every tenth data point over the second Link.
+// See:
+//   - Distributor
+//   - Weight
+func Weighted() NodeOpt {
+	return distributeBy(weightedMode)
+}
+
+// LeastQueued enables a Node to distribute incoming data among its outgoing links by sending data over the Link
+// holding the least data, Link(s) holding the same count of data take turns.
+// See:
+//   - Distributor
+//   - Size
+func LeastQueued() NodeOpt {
+	return distributeBy(leastQueuedMode)
+}
+
+// Random enables a Node to distribute incoming data among its outgoing links at random.
+// See:
+//   - Distributor
+func Random() NodeOpt {
+	return distributeBy(randomMode)
+}
+
+func distributeBy(mode strategyMode) NodeOpt {
+	return func(n *Node) {
+		n.distributor = true
+		n.strategy = &strategy{mode: mode}
+	}
+}
+
+// Weight sets the weight of the Link for the Weighted distribution of the from-node, it is 1 by default.
+// Weight below 1 counts as 1.
+// See:
+//   - Weighted
+func Weight(w int) LinkOpt {
+	return func(l *Link) {
+		l.weight = w
+	}
+}
+
+// pick returns the Link to send data over out of the Link(s) in the order of their to-node keys.
+// Must be called with Network.mu held, as queued data is counted.
+func (s *strategy) pick(links []*Link) *Link {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.mode {
+	case weightedMode:
+		// smooth weighted round-robin
+		current := make(map[*Link]int, len(links))
+		total := 0
+		var picked *Link
+		for _, link := range links {
+			w := max(link.weight, 1)
+			total += w
+			current[link] = s.current[link] + w
+			if picked == nil || current[link] > current[picked] {
+				picked = link
+			}
+		}
+		current[picked] -= total
+		s.current = current
+		return picked
+	case leastQueuedMode:
+		var picked *Link
+		for i := range links {
+			link := links[(s.next+i)%len(links)]
+			if picked == nil || len(link.ch) < len(picked.ch) {
+				picked = link
+			}
+		}
+		s.next++
+		return picked
+	case randomMode:
+		return links[rand.IntN(len(links))]
+	default:
+		picked := links[s.next%len(links)]
+		s.next++
+		return picked
+	}
+}
+
+// String describes the strategy as it appears in the DOT description of the Network.
+func (s *strategy) String() string {
+	if s == nil {
+		return ""
+	}
+	switch s.mode {
+	case weightedMode:
+		return "weighted"
+	case leastQueuedMode:
+		return "least-queued"
+	case randomMode:
+		return "random"
+	default:
+		return "round-robin"
+	}
+}
+
+// parseStrategy returns NodeOpt for the strategy described by String.
+func parseStrategy(s string) (NodeOpt, bool) {
+	switch s {
+	case "round-robin":
+		return RoundRobin(), true
+	case "weighted":
+		return Weighted(), true
+	case "least-queued":
+		return LeastQueued(), true
+	case "random":
+		return Random(), true
+	default:
+		return nil, false
+	}
+}
+
+// distributeByStrategy sends data over the Link picked by the strategy of the distributor Node.
+// Paused Link(s) are passed over, and data waits while all the Link(s) are paused.
+// It returns false if ctx is done before data is sent.
+func (n *Network) distributeByStrategy(ctx context.Context, node *Node, p packet) bool {
+	for {
+		n.mu.RLock()
+		changed := n.changed
+		var links []*Link
+		routes := 0
+		for _, link := range n.egress[node.Key()] {
+			if link.removed.Load() || link.deadLetter {
+				continue
+			}
+			routes++
+			if !link.paused.Load() {
+				links = append(links, link)
+			}
+		}
+		var picked *Link
+		if len(links) > 0 {
+			slices.SortFunc(links, func(a, b *Link) int {
+				return strings.Compare(a.y.Key(), b.y.Key())
+			})
+			picked = node.strategy.pick(links)
+		}
+		n.mu.RUnlock()
+
+		switch {
+		case routes == 0:
+			n.log().Debug("No route for data", "node", node.Key(), n.payload(p.data))
+			return true
+		case picked == nil:
+			n.log().Debug("Holding data for paused links", "node", node.Key(), n.payload(p.data))
+			select {
+			case <-ctx.Done():
+				return false
+			case <-changed:
+				continue
+			}
+		default:
+			n.log().Debug("Distributing data", "node", node.Key(), "strategy", node.strategy, linkAttr(picked), n.payload(p.data))
+			return n.send(ctx, picked, p)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package glow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Typed is a handle to a Node that consumes data of type I and produces data of type O.
+// Typed handles are linked using AddLinkOf, which ensures at compile time that
+// the output type of the from-node matches the input type of the to-node.
+//
+// Typed handles are thin wrappers around Node keys, therefore all the regular
+// Network functions (e.g. Node, RemoveLink, DOT) work with Typed.Key.
+type Typed[I, O any] struct {
+	key string
+}
+
+// Key returns the key of the Node.
+func (t Typed[I, O]) Key() string {
+	return t.key
+}
+
+// BasicFuncOf is the type-safe variant of BasicFunc.
+// Incoming data is asserted to type I before calling the function.
+// A seed-node receives zero value of type I.
+func BasicFuncOf[I, O any](f func(ctx context.Context, data I) (O, error)) NodeOpt {
+	return func(n *Node) {
+		n.in = reflect.TypeFor[I]()
+		n.out = reflect.TypeFor[O]()
+		n.f = func(ctx context.Context, data any) (any, error) {
+			in, err := assertType[I](n.key, data)
+			if err != nil {
+				return nil, err
+			}
+			return f(ctx, in)
+		}
+	}
+}
+
+// EmitFuncOf is the type-safe variant of EmitFunc.
+// Incoming data is asserted to type I before calling the function.
+// A seed-node receives zero value of type I.
+func EmitFuncOf[I, O any](f func(ctx context.Context, data I, emit func(O)) error) NodeOpt {
+	return func(n *Node) {
+		n.in = reflect.TypeFor[I]()
+		n.out = reflect.TypeFor[O]()
+		n.ef = func(ctx context.Context, data any, emit func(any)) error {
+			in, err := assertType[I](n.key, data)
+			if err != nil {
+				return err
+			}
+			return f(ctx, in, func(out O) {
+				emit(out)
+			})
+		}
+	}
+}
+
+// AddNodeOf adds a new Node with BasicFuncOf in the Network.
+// See:
+//   - AddNode
+func AddNodeOf[I, O any](n *Network, f func(ctx context.Context, data I) (O, error), opt ...NodeOpt) (Typed[I, O], error) {
+	key, err := n.AddNode(append(slices.Clip(opt), BasicFuncOf(f))...)
+	return Typed[I, O]{key: key}, err
+}
+
+// AddEmitNodeOf adds a new Node with EmitFuncOf in the Network.
+// See:
+//   - AddNode
+func AddEmitNodeOf[I, O any](n *Network, f func(ctx context.Context, data I, emit func(O)) error, opt ...NodeOpt) (Typed[I, O], error) {
+	key, err := n.AddNode(append(slices.Clip(opt), EmitFuncOf(f))...)
+	return Typed[I, O]{key: key}, err
+}
+
+// AddLinkOf connects from-node to to-node, where output of from-node is input of to-node.
+// See:
+//   - AddLink
+func AddLinkOf[I, T, O any](n *Network, from Typed[I, T], to Typed[T, O], opt ...LinkOpt) error {
+	return n.AddLink(from.Key(), to.Key(), opt...)
+}
+
+// Cast converts data to type T, nil data is converted to zero value of type T.
+// Data of another type fails with ErrTypeMismatch.
+func Cast[T any](data any) (T, error) {
+	var v T
+	if data == nil {
+		return v, nil
+	}
+	v, ok := data.(T)
+	if !ok {
+		return v, fmt.Errorf("%w: expected %s, got %T", ErrTypeMismatch, reflect.TypeFor[T](), data)
+	}
+	return v, nil
+}
+
+// assertType converts data to type T.
+// nil data is converted to zero value of type T, as seed-nodes receive no data.
+func assertType[T any](key string, data any) (T, error) {
+	v, err := Cast[T](data)
+	if err != nil {
+		return v, fmt.Errorf("node %s: %w", key, err)
+	}
+	return v, nil
+}
+
+// typesMatch reports whether output of type out can be fed to input of type in.
+// Untyped nodes match any type. Interface outputs are checked at runtime
+// as their dynamic types are not known ahead of time.
+func typesMatch(out, in reflect.Type) bool {
+	if out == nil || in == nil {
+		return true
+	}
+	if out.Kind() == reflect.Interface {
+		return true
+	}
+	return out.AssignableTo(in)
+}